@@ -2,16 +2,19 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/overseven/go-math-expression-parser/interfaces"
 	expp "github.com/overseven/go-math-expression-parser/parser"
 )
 
 // Foo - example of user-defined function
 func Foo(a ...float64) (float64, error) {
-	fmt.Println("foo was called!")
+	fmt.Fprintln(os.Stderr, "foo was called!")
 	var sum float64
 	for _, val := range a {
 		sum += val
@@ -20,9 +23,23 @@ func Foo(a ...float64) (float64, error) {
 }
 
 func main() {
+	// subcommand gen writes Go code of the expression
+	if len(os.Args) > 1 && os.Args[1] == "gen" {
+		if err := Generate(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "Error: ", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// add flag to print example
 	exampleFlag := flag.Bool("example", false, "print example of usage")
 	treeFlag := flag.Bool("tree", false, "print parsed tree of execution")
+	treeFormat := flag.String("tree-format", "", "format of the printed tree: prefix, ascii or dot (implies -tree)")
+	treeValues := flag.Bool("tree-values", false, "print the tree with values of the nodes after the evaluation, "+
+		"in ascii format by default")
+	rpnFlag := flag.Bool("rpn", false, "read the expression in reverse Polish notation: a b + 2 *")
+	toRPNFlag := flag.Bool("to-rpn", false, "print the expression in reverse Polish notation")
 	flag.Parse()
 
 	if *exampleFlag {
@@ -30,30 +47,64 @@ func main() {
 		return
 	}
 
+	// the prefix format doesn't show values of the nodes
+	switch {
+	case *treeValues && *treeFormat == "":
+		*treeFormat = "ascii"
+	case *treeValues && *treeFormat == "prefix":
+		fmt.Fprintln(os.Stderr, "Error: tree format 'prefix' doesn't show values, use ascii or dot")
+		os.Exit(2)
+	case *treeFormat != "" && *treeFormat != "prefix" && *treeFormat != "ascii" && *treeFormat != "dot":
+		fmt.Fprintln(os.Stderr, "Error: unknown tree format '"+*treeFormat+"'")
+		os.Exit(2)
+	}
+
+	// prompts and errors are written to stderr, the DOT tree is the only output of stdout
+	out := os.Stdout
+	if *treeFormat == "dot" {
+		out = os.Stderr
+	}
+
 	parser := expp.NewParser()
 	// add user function for parsing
 	parser.AddFunction(Foo, "foo")
 
-	fmt.Println("Input a math expression:")
+	fmt.Fprintln(os.Stderr, "Input a math expression:")
 
 	// input expression
 	reader := bufio.NewReader(os.Stdin)
 	formula, err := reader.ReadString('\n')
 	if err != nil {
-		fmt.Println("Error: ", err)
+		fmt.Fprintln(os.Stderr, "Error: ", err)
 		return
 	}
 
 	// parsing expression
-	exp, err := parser.Parse(formula)
+	var exp interfaces.Expression
+	if *rpnFlag {
+		exp, err = parser.ParseRPN(formula)
+	} else {
+		exp, err = parser.Parse(formula)
+	}
 	if err != nil {
-		fmt.Println("Error: ", err)
+		fmt.Fprintln(os.Stderr, "Error: ", err)
 		return
 	}
 
-	// print parsed tree if flag -tree is presented
-	if *treeFlag {
-		fmt.Println("\nParsed execution tree:", exp)
+	// print the expression in RPN if flag -to-rpn is presented
+	if *toRPNFlag {
+		tokens, err := parser.ToRPN(exp)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: ", err)
+			return
+		}
+		fmt.Fprintln(out, "RPN: ", strings.Join(tokens, " "))
+	}
+
+	// print parsed tree if flag -tree or -tree-format is presented
+	printTree := *treeFlag || *treeFormat != "" || *treeValues
+	if printTree && !*treeValues {
+		PrintTree(parser, exp, *treeFormat, nil)
 	}
 
 	// get list of the variables used in the expression
@@ -64,11 +115,11 @@ func main() {
 
 	// fill map
 	for _, v := range varsNeeded {
-		fmt.Print(v + " = ")
+		fmt.Fprint(os.Stderr, v+" = ")
 		var val float64
-		_, err := fmt.Scan(&val)
+		_, err := fmt.Fscan(reader, &val)
 		if err != nil {
-			fmt.Println("Incorrect value!")
+			fmt.Fprintln(os.Stderr, "Incorrect value!")
 			return
 		}
 		vars[v] = val
@@ -77,11 +128,58 @@ func main() {
 	// execute the expression using values of variables
 	result, err := parser.Evaluate(vars)
 	if err != nil {
-		fmt.Println("Error: ", err)
+		fmt.Fprintln(os.Stderr, "Error: ", err)
 	}
 
 	// print result value
-	fmt.Println("Result: ", result)
+	fmt.Fprintln(out, "Result: ", parser.FormatNumber(result))
+
+	if *treeValues {
+		PrintTree(parser, exp, *treeFormat, vars)
+	}
+}
+
+// Generate writes Go function of the expression, it's used by go generate:
+//
+//	//go:generate go run github.com/overseven/go-math-expression-parser gen -pkg pricing -func Total -o total.go "price * qty"
+func Generate(args []string) error {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	pkg := flags.String("pkg", "main", "package of the generated file")
+	name := flags.String("func", "Eval", "name of the generated function")
+	out := flags.String("o", "", "output file, the code is printed if it's empty")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("usage: gen [-pkg name] [-func name] [-o file] expression")
+	}
+
+	parser := expp.NewParser()
+	exp, err := parser.Parse(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	src, err := parser.GenerateGo(exp, *pkg, *name)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		fmt.Print(string(src))
+		return nil
+	}
+	return os.WriteFile(*out, src, 0o644)
+}
+
+// PrintTree prints the tree in the format, values of the nodes are printed if vars isn't nil
+func PrintTree(parser *expp.Parser, exp interfaces.Expression, format string, vars map[string]float64) {
+	switch format {
+	case "", "prefix":
+		fmt.Println("\nParsed execution tree:", exp)
+	case "ascii":
+		fmt.Print("\nParsed execution tree:\n" + parser.TreeASCII(exp, vars))
+	case "dot":
+		fmt.Print(parser.TreeDOT(exp, vars))
+	default:
+		fmt.Fprintln(os.Stderr, "Error: unknown tree format '"+format+"'")
+	}
 }
 
 // PrintExample prints instructions if flag -example is presented
@@ -0,0 +1,225 @@
+package dual
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/overseven/go-math-expression-parser/funcs"
+	"github.com/overseven/go-math-expression-parser/interfaces"
+)
+
+// Dual - a value and its gradient with respect to the chosen variables
+type Dual struct {
+	Val  float64
+	Grad []float64
+}
+
+// Const - create a dual number with zero gradient
+func Const(val float64, n int) Dual {
+	return Dual{Val: val, Grad: make([]float64, n)}
+}
+
+// Variable - create a dual number for the i-th of n variables of differentiation
+func Variable(val float64, i, n int) Dual {
+	d := Const(val, n)
+	d.Grad[i] = 1
+	return d
+}
+
+// toString conversation
+func (x Dual) String() string {
+	str := strconv.FormatFloat(x.Val, 'g', -1, 64) + " ["
+	for i, g := range x.Grad {
+		if i > 0 {
+			str += ", "
+		}
+		str += strconv.FormatFloat(g, 'g', -1, 64)
+	}
+	return str + "]"
+}
+
+// combine - create a dual number with gradient ka*a.Grad + kb*b.Grad
+func combine(val float64, ka float64, a Dual, kb float64, b Dual) Dual {
+	res := Dual{Val: val, Grad: make([]float64, len(a.Grad))}
+	for i := range res.Grad {
+		res.Grad[i] = ka*a.Grad[i] + kb*b.Grad[i]
+	}
+	return res
+}
+
+func scale(val float64, k float64, a Dual) Dual {
+	return combine(val, k, a, 0, a)
+}
+
+// Domain - evaluation of an expression over dual numbers (forward-mode automatic differentiation)
+type Domain struct {
+	n           int
+	functions   [funcs.LevelsOfOperators]map[string]funcs.FuncType
+	derivatives map[string][]funcs.FuncType
+}
+
+// NewDomain - create a Domain for n variables of differentiation.
+// derivatives contains partial derivatives of the user functions with respect to each argument
+func NewDomain(n int, functions [funcs.LevelsOfOperators]map[string]funcs.FuncType,
+	derivatives map[string][]funcs.FuncType) *Domain {
+	return &Domain{n: n, functions: functions, derivatives: derivatives}
+}
+
+func dual(v interfaces.Value) (Dual, error) {
+	x, ok := v.(Dual)
+	if !ok {
+		return Dual{}, errors.New("value is not a dual number")
+	}
+	return x, nil
+}
+
+// Const - number with zero gradient
+func (d *Domain) Const(val float64) (interfaces.Value, error) {
+	return Const(val, d.n), nil
+}
+
+// Literal - all terms must be numbers or variables
+func (d *Domain) Literal(term string) (interfaces.Value, error) {
+	return nil, errors.New("value '" + term + " not found in map")
+}
+
+// Binary - binary operator of funcs/basic and its derivative
+func (d *Domain) Binary(op string, x, y interfaces.Value) (interfaces.Value, error) {
+	a, err := dual(x)
+	if err != nil {
+		return nil, err
+	}
+	b, err := dual(y)
+	if err != nil {
+		return nil, err
+	}
+	var f funcs.FuncType
+	for i := 1; i < funcs.LevelsOfOperators && f == nil; i++ {
+		f = d.functions[i][op]
+	}
+	if f == nil {
+		return nil, errors.New("not supported binary operation: '" + op + "'")
+	}
+	val, err := f(a.Val, b.Val)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "+":
+		return combine(val, 1, a, 1, b), nil
+	case "-":
+		return combine(val, 1, a, -1, b), nil
+	case "*":
+		return combine(val, b.Val, a, a.Val, b), nil
+	case "/":
+		return combine(val, 1/b.Val, a, -a.Val/(b.Val*b.Val), b), nil
+	case "^":
+		// the chain terms of the constant operands are skipped, the derivative at them can be infinite
+		if isConst(b) && (isConst(a) || b.Val == 0) {
+			return Const(val, d.n), nil
+		}
+		if isConst(b) {
+			return scale(val, b.Val*math.Pow(a.Val, b.Val-1), a), nil
+		}
+		if a.Val <= 0 {
+			return nil, errors.New("derivative of power is undefined for non-positive base: " + strconv.FormatFloat(a.Val, 'g', -1, 64))
+		}
+		return combine(val, b.Val*math.Pow(a.Val, b.Val-1), a, val*math.Log(a.Val), b), nil
+	case "%", "<", "<=", ">", ">=", "==", "!=":
+		// reminder of the integer parts and comparisons are piecewise constant
+		return Const(val, d.n), nil
+	}
+	return nil, errors.New("derivative of operation '" + op + "' is not defined")
+}
+
+// Unary - unary operator and its derivative
+func (d *Domain) Unary(op string, x interfaces.Value) (interfaces.Value, error) {
+	a, err := dual(x)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "+":
+		return a, nil
+	case "-":
+		return scale(-a.Val, -1, a), nil
+	}
+	return d.Call(op, a)
+}
+
+// Call - function and its derivative. User functions need partial derivatives passed to AddFunction
+func (d *Domain) Call(name string, args ...interfaces.Value) (interfaces.Value, error) {
+	xs := make([]Dual, len(args))
+	vals := make([]float64, len(args))
+	for i, arg := range args {
+		x, err := dual(arg)
+		if err != nil {
+			return nil, err
+		}
+		xs[i], vals[i] = x, x.Val
+	}
+	f, ok := d.functions[0][name]
+	if !ok {
+		return nil, errors.New("function '" + name + "' is not supported")
+	}
+	val, err := f(vals...)
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case "sqrt":
+		if isConst(xs[0]) {
+			// the derivative is infinite at zero
+			return Const(val, d.n), nil
+		}
+		return scale(val, 0.5/val, xs[0]), nil
+	case "abs":
+		if xs[0].Val < 0 {
+			return scale(val, -1, xs[0]), nil
+		}
+		return scale(val, 1, xs[0]), nil
+	case "to":
+		return scale(val, 1, xs[0]), nil
+	}
+
+	res := Const(val, d.n)
+	partials := d.derivatives[name]
+	for i, x := range xs {
+		if isConst(x) {
+			continue
+		}
+		if i >= len(partials) {
+			return nil, errors.New("derivative of function '" + name + "' with respect to argument " + strconv.Itoa(i+1) + " is not defined")
+		}
+		k, err := partials[i](vals...)
+		if err != nil {
+			return nil, err
+		}
+		res = combine(val, 1, res, k, x)
+	}
+	return res, nil
+}
+
+// Truth - non-zero value is true
+func (d *Domain) Truth(cond interfaces.Value) (bool, bool, error) {
+	x, err := dual(cond)
+	if err != nil {
+		return false, false, err
+	}
+	return x.Val != 0, true, nil
+}
+
+// Join - the truth of dual number is always known, so the values are never joined
+func (d *Domain) Join(x, y interfaces.Value) (interfaces.Value, error) {
+	return nil, errors.New("dual numbers can't be joined")
+}
+
+func isConst(x Dual) bool {
+	for _, g := range x.Grad {
+		if g != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package dual_test
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/domains/dual"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+const float64EqualityThreshold = 1e-9
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) <= float64EqualityThreshold
+}
+
+func TestDualString(t *testing.T) {
+	x := dual.Variable(2.5, 1, 3)
+	if x.String() != "2.5 [0, 1, 0]" {
+		t.Error("incorrect string conversion = " + x.String())
+	}
+}
+
+func TestEvaluateGradient(t *testing.T) {
+	type TestVars map[string]float64
+	type TestData struct {
+		input  string
+		vars   TestVars
+		wrt    []string
+		output float64
+		grad   []float64
+	}
+
+	data := []TestData{
+		{"x*y + 3", TestVars{"x": 2, "y": 5}, []string{"x", "y"}, 13, []float64{5, 2}},
+		{"x / y", TestVars{"x": 3, "y": 2}, []string{"x", "y"}, 1.5, []float64{0.5, -0.75}},
+		{"x^3 - x", TestVars{"x": 2}, []string{"x"}, 6, []float64{11}},
+		{"2^x", TestVars{"x": 3}, []string{"x"}, 8, []float64{8 * math.Ln2}},
+		{"sqrt(x) + abs(-y)", TestVars{"x": 4, "y": -3}, []string{"x", "y"}, 5, []float64{0.25, -1}},
+		{"(price - cost) * qty", TestVars{"price": 10, "cost": 7, "qty": 4}, []string{"price", "qty"}, 12, []float64{4, 3}},
+		{"-x % 3", TestVars{"x": 7}, []string{"x"}, -1, []float64{0}},
+		{"sq(x + 1) * k", TestVars{"x": 2, "k": 10}, []string{"x"}, 90, []float64{60}},
+		{"sq(3)", TestVars{}, []string{}, 9, []float64{}},
+		{"mul(x, y)", TestVars{"x": 2, "y": 7}, []string{"x", "y"}, 14, []float64{7, 2}},
+		{"x + sqrt(c)", TestVars{"x": 2, "c": 0}, []string{"x"}, 2, []float64{1}},
+		{"x + c^0.5", TestVars{"x": 2, "c": 0}, []string{"x"}, 2, []float64{1}},
+		{"x^0", TestVars{"x": 0}, []string{"x"}, 1, []float64{0}},
+	}
+
+	p := parser.NewParser()
+	p.AddFunctionWithDerivatives(func(args ...float64) (float64, error) {
+		return args[0] * args[0], nil
+	}, "sq", func(args ...float64) (float64, error) {
+		return 2 * args[0], nil
+	})
+	p.AddFunctionWithDerivatives(func(args ...float64) (float64, error) {
+		return args[0] * args[1], nil
+	}, "mul", func(args ...float64) (float64, error) {
+		return args[1], nil
+	}, func(args ...float64) (float64, error) {
+		return args[0], nil
+	})
+
+	for _, d := range data {
+		_, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+		}
+		res, grad, err := p.EvaluateGradient(d.vars, d.wrt...)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !almostEqual(res, d.output) {
+			t.Error("incorrect result of '" + d.input + "': " + strconv.FormatFloat(res, 'e', 4, 64))
+		}
+		for i, name := range d.wrt {
+			if !almostEqual(grad[name], d.grad[i]) {
+				t.Error("incorrect derivative of '" + d.input + "' with respect to " + name + ": " + strconv.FormatFloat(grad[name], 'e', 4, 64))
+			}
+		}
+	}
+}
+
+func TestEvaluateGradientErrors(t *testing.T) {
+	p := parser.NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) {
+		return args[0] * 2, nil
+	}, "double")
+
+	type TestData struct {
+		input string
+		wrt   []string
+	}
+	data := []TestData{
+		{"double(x)", []string{"x"}},
+		{"x^x", []string{"x"}},
+		{"x + z", []string{"z"}},
+		{"x + z", []string{"x"}},
+	}
+	for _, d := range data {
+		_, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+		}
+		if _, _, err := p.EvaluateGradient(map[string]float64{"x": -1}, d.wrt...); err == nil {
+			t.Error("incorrect error handling of '" + d.input + "'")
+		}
+	}
+
+	// derivative is not needed for the constant arguments
+	p.Parse("double(3) * x")
+	_, grad, err := p.EvaluateGradient(map[string]float64{"x": -1}, "x")
+	if err != nil {
+		t.Error(err)
+	}
+	if !almostEqual(grad["x"], 6) {
+		t.Error("incorrect derivative: " + strconv.FormatFloat(grad["x"], 'e', 4, 64))
+	}
+}
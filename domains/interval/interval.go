@@ -0,0 +1,362 @@
+package interval
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/overseven/go-math-expression-parser/funcs"
+	"github.com/overseven/go-math-expression-parser/interfaces"
+)
+
+// Interval - closed range of real numbers [Lo, Hi]
+type Interval struct {
+	Lo, Hi float64
+}
+
+// New - create an interval, the bounds may be passed in any order
+func New(lo, hi float64) Interval {
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	return Interval{Lo: lo, Hi: hi}
+}
+
+// Point - create a degenerate interval [val, val]
+func Point(val float64) Interval {
+	return Interval{Lo: val, Hi: val}
+}
+
+// IsPoint - checks that the interval contains a single number
+func (x Interval) IsPoint() bool {
+	return x.Lo == x.Hi
+}
+
+// Contains - checks that the value is inside the interval
+func (x Interval) Contains(val float64) bool {
+	return x.Lo <= val && val <= x.Hi
+}
+
+// Width - distance between the bounds
+func (x Interval) Width() float64 {
+	return x.Hi - x.Lo
+}
+
+// toString conversation
+func (x Interval) String() string {
+	return "[" + strconv.FormatFloat(x.Lo, 'g', -1, 64) + ", " + strconv.FormatFloat(x.Hi, 'g', -1, 64) + "]"
+}
+
+// outward - widen the bounds by one ulp to compensate rounding errors
+func outward(lo, hi float64) Interval {
+	return Interval{Lo: math.Nextafter(lo, math.Inf(-1)), Hi: math.Nextafter(hi, math.Inf(1))}
+}
+
+// mul - multiplication of bounds, where 0 * Inf is 0
+func mul(a, b float64) float64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return a * b
+}
+
+func hull(vals ...float64) (lo, hi float64) {
+	lo, hi = vals[0], vals[0]
+	for _, v := range vals[1:] {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	return lo, hi
+}
+
+// Add - sum of intervals
+func Add(x, y Interval) Interval {
+	return outward(x.Lo+y.Lo, x.Hi+y.Hi)
+}
+
+// Sub - difference of intervals
+func Sub(x, y Interval) Interval {
+	return outward(x.Lo-y.Hi, x.Hi-y.Lo)
+}
+
+// Mul - product of intervals
+func Mul(x, y Interval) Interval {
+	return outward(hull(mul(x.Lo, y.Lo), mul(x.Lo, y.Hi), mul(x.Hi, y.Lo), mul(x.Hi, y.Hi)))
+}
+
+// Div - quotient of intervals. Division by an interval, which contains zero, gives unbounded result
+func Div(x, y Interval) (Interval, error) {
+	if y.Lo == 0 && y.Hi == 0 {
+		return Interval{}, errors.New("incorrect divisor for division operator")
+	}
+	var inv Interval
+	switch {
+	case y.Lo > 0 || y.Hi < 0:
+		inv = Interval{Lo: 1 / y.Hi, Hi: 1 / y.Lo}
+	case y.Lo == 0:
+		inv = Interval{Lo: 1 / y.Hi, Hi: math.Inf(1)}
+	case y.Hi == 0:
+		inv = Interval{Lo: math.Inf(-1), Hi: 1 / y.Lo}
+	default:
+		inv = Interval{Lo: math.Inf(-1), Hi: math.Inf(1)}
+	}
+	return Mul(x, inv), nil
+}
+
+// Pow - power of interval. The base must be non-negative, if the exponent isn't an integer number
+func Pow(x, y Interval) (Interval, error) {
+	if y.IsPoint() && y.Lo == math.Trunc(y.Lo) {
+		return powInt(x, y.Lo)
+	}
+	if x.Lo < 0 {
+		return Interval{}, errors.New("power " + y.String() + " of interval " + x.String() + " with negative values")
+	}
+	if x.Lo == 0 && y.Lo < 0 {
+		return Interval{}, errors.New("negative power " + y.String() + " of interval " + x.String() + " with zero")
+	}
+	return outward(hull(math.Pow(x.Lo, y.Lo), math.Pow(x.Lo, y.Hi), math.Pow(x.Hi, y.Lo), math.Pow(x.Hi, y.Hi))), nil
+}
+
+func powInt(x Interval, n float64) (Interval, error) {
+	if n < 0 {
+		res, err := powInt(x, -n)
+		if err != nil {
+			return Interval{}, err
+		}
+		return Div(Point(1), res)
+	}
+	lo, hi := math.Pow(x.Lo, n), math.Pow(x.Hi, n)
+	if math.Mod(n, 2) == 0 {
+		switch {
+		case x.Contains(0):
+			return outward(0, math.Max(lo, hi)), nil
+		case x.Hi < 0:
+			return outward(hi, lo), nil
+		}
+	}
+	return outward(lo, hi), nil
+}
+
+// Mod - reminder of truncated division of the integer parts, like funcs/basic '%' operator
+func Mod(x, y Interval) (Interval, error) {
+	m1, m2 := math.Trunc(y.Lo), math.Trunc(y.Hi)
+	if m1 <= 0 && m2 >= 0 {
+		return Interval{}, errors.New("incorrect divisor for % operator")
+	}
+	a, b := math.Trunc(x.Lo), math.Trunc(x.Hi)
+	if m1 == m2 && int(a)/int(m1) == int(b)/int(m1) && (a >= 0 || b <= 0) {
+		return Interval{Lo: float64(int(a) % int(m1)), Hi: float64(int(b) % int(m1))}, nil
+	}
+	limit := math.Max(math.Abs(m1), math.Abs(m2)) - 1
+	res := Interval{Lo: -limit, Hi: limit}
+	if a >= 0 {
+		res.Lo = 0
+	}
+	if b <= 0 {
+		res.Hi = 0
+	}
+	return res, nil
+}
+
+// truth - [1, 1] if the condition is true for all values, [0, 0] if it is false for all values, else [0, 1]
+func truth(always, never bool) Interval {
+	switch {
+	case always:
+		return Point(1)
+	case never:
+		return Point(0)
+	}
+	return Interval{Lo: 0, Hi: 1}
+}
+
+// Less - comparison x < y for all values of intervals
+func Less(x, y Interval) Interval {
+	return truth(x.Hi < y.Lo, x.Lo >= y.Hi)
+}
+
+// LessOrEqual - comparison x <= y for all values of intervals
+func LessOrEqual(x, y Interval) Interval {
+	return truth(x.Hi <= y.Lo, x.Lo > y.Hi)
+}
+
+// Equal - comparison x == y for all values of intervals
+func Equal(x, y Interval) Interval {
+	return truth(x.IsPoint() && x == y, x.Hi < y.Lo || y.Hi < x.Lo)
+}
+
+// Sqrt - square root of the non-negative interval
+func Sqrt(x Interval) (Interval, error) {
+	if x.Lo < 0 {
+		return Interval{}, errors.New("'sqrt' function argument contains negative values: " + x.String())
+	}
+	return outward(math.Sqrt(x.Lo), math.Sqrt(x.Hi)), nil
+}
+
+// Abs - absolute value of interval
+func Abs(x Interval) Interval {
+	switch {
+	case x.Lo >= 0:
+		return x
+	case x.Hi <= 0:
+		return Interval{Lo: -x.Hi, Hi: -x.Lo}
+	}
+	return Interval{Lo: 0, Hi: math.Max(-x.Lo, x.Hi)}
+}
+
+// Domain - evaluation of an expression over intervals
+type Domain struct {
+	functions [funcs.LevelsOfOperators]map[string]funcs.FuncType
+}
+
+// NewDomain - create a Domain, user functions are supported only for degenerate intervals
+func NewDomain(functions [funcs.LevelsOfOperators]map[string]funcs.FuncType) *Domain {
+	return &Domain{functions: functions}
+}
+
+func interval(v interfaces.Value) (Interval, error) {
+	x, ok := v.(Interval)
+	if !ok {
+		return Interval{}, errors.New("value is not an interval")
+	}
+	return x, nil
+}
+
+// Const - degenerate interval
+func (d *Domain) Const(val float64) (interfaces.Value, error) {
+	return Point(val), nil
+}
+
+// Literal - all terms must be numbers or variables
+func (d *Domain) Literal(term string) (interfaces.Value, error) {
+	return nil, errors.New("value '" + term + " not found in map")
+}
+
+// Binary - binary operator of funcs/basic over intervals
+func (d *Domain) Binary(op string, x, y interfaces.Value) (interfaces.Value, error) {
+	a, err := interval(x)
+	if err != nil {
+		return nil, err
+	}
+	b, err := interval(y)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "+":
+		return Add(a, b), nil
+	case "-":
+		return Sub(a, b), nil
+	case "*":
+		return Mul(a, b), nil
+	case "/":
+		return Div(a, b)
+	case "^":
+		return Pow(a, b)
+	case "%":
+		return Mod(a, b)
+	case "<":
+		return Less(a, b), nil
+	case "<=":
+		return LessOrEqual(a, b), nil
+	case ">":
+		return Less(b, a), nil
+	case ">=":
+		return LessOrEqual(b, a), nil
+	case "==":
+		return Equal(a, b), nil
+	case "!=":
+		eq := Equal(a, b)
+		return Interval{Lo: 1 - eq.Hi, Hi: 1 - eq.Lo}, nil
+	}
+	return nil, errors.New("not supported binary operation: '" + op + "'")
+}
+
+// Unary - unary operator over interval
+func (d *Domain) Unary(op string, x interfaces.Value) (interfaces.Value, error) {
+	a, err := interval(x)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "+":
+		return a, nil
+	case "-":
+		return Interval{Lo: -a.Hi, Hi: -a.Lo}, nil
+	}
+	return d.Call(op, a)
+}
+
+// Call - 'sqrt', 'abs' and 'to' functions over intervals, other functions accept only degenerate intervals
+func (d *Domain) Call(name string, args ...interfaces.Value) (interfaces.Value, error) {
+	xs := make([]Interval, len(args))
+	for i, arg := range args {
+		x, err := interval(arg)
+		if err != nil {
+			return nil, err
+		}
+		xs[i] = x
+	}
+	switch name {
+	case "sqrt":
+		if len(xs) != 1 {
+			return nil, errors.New("incorrect count of args for 'sqrt' function. Need: 1, but get: " + strconv.Itoa(len(xs)))
+		}
+		return Sqrt(xs[0])
+	case "abs":
+		if len(xs) != 1 {
+			return nil, errors.New("incorrect count of args for 'abs' function. Need: 1, but get: " + strconv.Itoa(len(xs)))
+		}
+		return Abs(xs[0]), nil
+	case "to":
+		if len(xs) != 2 {
+			return nil, errors.New("incorrect count of args for 'to' function. Need: 2, but get: " + strconv.Itoa(len(xs)))
+		}
+		return xs[0], nil
+	}
+	return d.call(name, xs...)
+}
+
+// Truth - the condition is known, if the interval doesn't contain zero or it is [0, 0]
+func (d *Domain) Truth(cond interfaces.Value) (bool, bool, error) {
+	x, err := interval(cond)
+	if err != nil {
+		return false, false, err
+	}
+	if !x.Contains(0) {
+		return true, true, nil
+	}
+	return false, x.IsPoint(), nil
+}
+
+// Join - the smallest interval, which contains both intervals
+func (d *Domain) Join(x, y interfaces.Value) (interfaces.Value, error) {
+	a, err := interval(x)
+	if err != nil {
+		return nil, err
+	}
+	b, err := interval(y)
+	if err != nil {
+		return nil, err
+	}
+	return Interval{Lo: math.Min(a.Lo, b.Lo), Hi: math.Max(a.Hi, b.Hi)}, nil
+}
+
+// call - fallback to the float function for degenerate intervals
+func (d *Domain) call(name string, args ...Interval) (Interval, error) {
+	f, ok := d.functions[0][name]
+	if !ok {
+		return Interval{}, errors.New("function '" + name + "' is not supported")
+	}
+	vals := make([]float64, len(args))
+	for i, x := range args {
+		if !x.IsPoint() {
+			return Interval{}, errors.New("function '" + name + "' is not supported for intervals")
+		}
+		vals[i] = x.Lo
+	}
+	res, err := f(vals...)
+	if err != nil {
+		return Interval{}, err
+	}
+	return Point(res), nil
+}
@@ -0,0 +1,139 @@
+package interval_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/domains/interval"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+const float64EqualityThreshold = 1e-9
+
+func almostEqual(a, b float64) bool {
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return a == b
+	}
+	return math.Abs(a-b) <= float64EqualityThreshold
+}
+
+func intervalsAreEqual(x, y interval.Interval) bool {
+	return almostEqual(x.Lo, y.Lo) && almostEqual(x.Hi, y.Hi)
+}
+
+func TestOperators(t *testing.T) {
+	type TestData struct {
+		name   string
+		res    interval.Interval
+		output interval.Interval
+	}
+	div1, _ := interval.Div(interval.New(1, 2), interval.New(4, 8))
+	div2, _ := interval.Div(interval.New(1, 2), interval.New(0, 4))
+	div3, _ := interval.Div(interval.New(1, 2), interval.New(-4, 0))
+	div4, _ := interval.Div(interval.New(1, 2), interval.New(-1, 1))
+	pow1, _ := interval.Pow(interval.New(-2, 3), interval.Point(2))
+	pow2, _ := interval.Pow(interval.New(-3, -2), interval.Point(3))
+	pow3, _ := interval.Pow(interval.New(1, 4), interval.Point(0.5))
+	pow4, _ := interval.Pow(interval.New(2, 4), interval.Point(-1))
+	sqrt, _ := interval.Sqrt(interval.New(4, 9))
+	mod1, _ := interval.Mod(interval.New(11, 13), interval.Point(5))
+	mod2, _ := interval.Mod(interval.New(3, 13), interval.Point(5))
+
+	data := []TestData{
+		{"add", interval.Add(interval.New(1, 2), interval.New(-3, 5)), interval.New(-2, 7)},
+		{"sub", interval.Sub(interval.New(1, 2), interval.New(-3, 5)), interval.New(-4, 5)},
+		{"mul", interval.Mul(interval.New(-1, 2), interval.New(-3, 5)), interval.New(-6, 10)},
+		{"div", div1, interval.New(0.125, 0.5)},
+		{"div by [0, 4]", div2, interval.New(0.25, math.Inf(1))},
+		{"div by [-4, 0]", div3, interval.New(math.Inf(-1), -0.25)},
+		{"div by [-1, 1]", div4, interval.New(math.Inf(-1), math.Inf(1))},
+		{"even pow", pow1, interval.New(0, 9)},
+		{"odd pow", pow2, interval.New(-27, -8)},
+		{"fractional pow", pow3, interval.New(1, 2)},
+		{"negative pow", pow4, interval.New(0.25, 0.5)},
+		{"sqrt", sqrt, interval.New(2, 3)},
+		{"abs", interval.Abs(interval.New(-5, 3)), interval.New(0, 5)},
+		{"mod", mod1, interval.New(1, 3)},
+		{"mod with several periods", mod2, interval.New(0, 4)},
+		{"less", interval.Less(interval.New(1, 2), interval.New(3, 4)), interval.Point(1)},
+		{"not less", interval.Less(interval.New(3, 4), interval.New(1, 3)), interval.Point(0)},
+		{"overlapped less", interval.Less(interval.New(1, 3), interval.New(2, 4)), interval.New(0, 1)},
+		{"less or equal", interval.LessOrEqual(interval.New(1, 2), interval.New(2, 4)), interval.Point(1)},
+		{"equal", interval.Equal(interval.Point(2), interval.Point(2)), interval.Point(1)},
+		{"not equal", interval.Equal(interval.New(1, 2), interval.New(3, 4)), interval.Point(0)},
+		{"overlapped equal", interval.Equal(interval.New(1, 3), interval.New(2, 4)), interval.New(0, 1)},
+	}
+	for _, d := range data {
+		if !intervalsAreEqual(d.res, d.output) {
+			t.Error("incorrect " + d.name + " result: " + d.res.String() + ", need: " + d.output.String())
+		}
+	}
+
+	if _, err := interval.Div(interval.New(1, 2), interval.Point(0)); err == nil {
+		t.Error("incorrect Div error handling")
+	}
+	if _, err := interval.Sqrt(interval.New(-1, 4)); err == nil {
+		t.Error("incorrect Sqrt error handling")
+	}
+	if _, err := interval.Pow(interval.New(-1, 4), interval.Point(0.5)); err == nil {
+		t.Error("incorrect Pow error handling")
+	}
+	if _, err := interval.Mod(interval.New(1, 4), interval.New(-1, 1)); err == nil {
+		t.Error("incorrect Mod error handling")
+	}
+}
+
+func TestBoundsAreGuaranteed(t *testing.T) {
+	res := interval.Add(interval.Point(0.1), interval.Point(0.2))
+	if !res.Contains(0.1+0.2) || res.Lo >= res.Hi {
+		t.Error("incorrect rounding of bounds: " + res.String())
+	}
+}
+
+func TestEvaluateInterval(t *testing.T) {
+	type TestVars map[string]interval.Interval
+	type TestData struct {
+		input  string
+		vars   TestVars
+		output interval.Interval
+	}
+
+	data := []TestData{
+		{"x + y", TestVars{"x": interval.New(1, 2), "y": interval.New(10, 20)}, interval.New(11, 22)},
+		{"x - x", TestVars{"x": interval.New(1, 2)}, interval.New(-1, 1)},
+		{"r^2 * 3", TestVars{"r": interval.New(-1, 2)}, interval.New(0, 12)},
+		{"sqrt(a) + abs(-b)", TestVars{"a": interval.New(4, 16), "b": interval.New(-1, 1)}, interval.New(2, 5)},
+		{"10 / (x - 1)", TestVars{"x": interval.New(2, 6)}, interval.New(2, 10)},
+		{"-x", TestVars{"x": interval.New(2, 6)}, interval.New(-6, -2)},
+		{"foo(2, 3)", TestVars{}, interval.Point(5)},
+	}
+
+	p := parser.NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) {
+		return args[0] + args[1], nil
+	}, "foo")
+	for _, d := range data {
+		_, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+		}
+		res, err := p.EvaluateInterval(d.vars)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !intervalsAreEqual(res, d.output) {
+			t.Error("incorrect result of '" + d.input + "': " + res.String() + ", need: " + d.output.String())
+		}
+	}
+
+	for _, input := range []string{"foo(x, 1)", "sqrt(x)", "z"} {
+		_, err := p.Parse(input)
+		if err != nil {
+			t.Error(err)
+		}
+		if _, err := p.EvaluateInterval(TestVars{"x": interval.New(-1, 1)}); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package uncertainty
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// Measurement - measured value with its standard deviation (x ± σ)
+type Measurement struct {
+	Value float64
+	Sigma float64
+}
+
+// toString conversation
+func (m Measurement) String() string {
+	return strconv.FormatFloat(m.Value, 'g', -1, 64) + " ± " + strconv.FormatFloat(m.Sigma, 'g', -1, 64)
+}
+
+// Correlation - correlation matrix of the variables, rows and columns are in the Vars order.
+// Variables which are not listed are uncorrelated
+type Correlation struct {
+	Vars   []string
+	Matrix [][]float64
+}
+
+// Validate - checks that the matrix is a symmetric matrix of correlation coefficients of known variables
+func (c *Correlation) Validate(vars map[string]Measurement) error {
+	if len(c.Matrix) != len(c.Vars) {
+		return errors.New("incorrect size of correlation matrix. Need: " + strconv.Itoa(len(c.Vars)) + ", but get: " + strconv.Itoa(len(c.Matrix)))
+	}
+	// all rows are checked before the symmetry, which reads the columns of the next rows
+	for i, row := range c.Matrix {
+		if len(row) != len(c.Vars) {
+			return errors.New("incorrect size of correlation matrix row " + strconv.Itoa(i))
+		}
+	}
+	for i, name := range c.Vars {
+		if _, ok := vars[name]; !ok {
+			return errors.New("value '" + name + " not found in map")
+		}
+		if c.Matrix[i][i] != 1 {
+			return errors.New("self-correlation of '" + name + "' must be 1")
+		}
+		for j, r := range c.Matrix[i] {
+			if r < -1 || r > 1 || r != c.Matrix[j][i] {
+				return errors.New("incorrect correlation of '" + name + "' and '" + c.Vars[j] + "'")
+			}
+		}
+	}
+	return nil
+}
+
+// covariance - covariance matrix of the variables in the names order
+func covariance(names []string, vars map[string]Measurement, corr *Correlation) [][]float64 {
+	index := make(map[string]int)
+	if corr != nil {
+		for i, name := range corr.Vars {
+			index[name] = i
+		}
+	}
+	cov := make([][]float64, len(names))
+	for i, a := range names {
+		cov[i] = make([]float64, len(names))
+		for j, b := range names {
+			r := 0.0
+			if i == j {
+				r = 1
+			} else if ia, ok := index[a]; ok {
+				if ib, ok := index[b]; ok {
+					r = corr.Matrix[ia][ib]
+				}
+			}
+			cov[i][j] = r * vars[a].Sigma * vars[b].Sigma
+		}
+	}
+	return cov
+}
+
+// Uncertain - sorted names of the variables with non-zero standard deviation
+func Uncertain(vars map[string]Measurement) []string {
+	var names []string
+	for name, m := range vars {
+		if m.Sigma != 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validate - standard deviations must be non-negative finite numbers
+func validate(vars map[string]Measurement, corr *Correlation) error {
+	for _, name := range Uncertain(vars) {
+		if sigma := vars[name].Sigma; sigma < 0 || math.IsNaN(sigma) || math.IsInf(sigma, 0) {
+			return errors.New("incorrect standard deviation of '" + name + "': " + strconv.FormatFloat(sigma, 'g', -1, 64))
+		}
+	}
+	if corr != nil {
+		return corr.Validate(vars)
+	}
+	return nil
+}
+
+// Linear - first-order propagation of uncertainty, grad is the gradient of the result
+// with respect to the variables
+func Linear(val float64, grad map[string]float64, vars map[string]Measurement, corr *Correlation) (Measurement, error) {
+	if err := validate(vars, corr); err != nil {
+		return Measurement{}, err
+	}
+	names := Uncertain(vars)
+	cov := covariance(names, vars, corr)
+	variance := 0.0
+	for i, a := range names {
+		for j, b := range names {
+			variance += grad[a] * grad[b] * cov[i][j]
+		}
+	}
+	return Measurement{Value: val, Sigma: math.Sqrt(math.Max(variance, 0))}, nil
+}
+
+// cholesky - lower triangular L, where L * L^T = cov. Positive semidefinite matrices are allowed
+func cholesky(cov [][]float64) ([][]float64, error) {
+	const eps = 1e-12
+	l := make([][]float64, len(cov))
+	for i := range cov {
+		l[i] = make([]float64, len(cov))
+		for j := 0; j <= i; j++ {
+			sum := cov[i][j]
+			for k := 0; k < j; k++ {
+				sum -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if sum < -eps*math.Max(cov[i][i], 1) {
+					return nil, errors.New("correlation matrix is not positive semidefinite")
+				}
+				l[i][i] = math.Sqrt(math.Max(sum, 0))
+			} else if l[j][j] > eps {
+				l[i][j] = sum / l[j][j]
+			}
+		}
+	}
+	return l, nil
+}
+
+// MonteCarlo - propagation of uncertainty by sampling of normally distributed variables,
+// return the mean and the standard deviation of the results
+func MonteCarlo(eval func(vars map[string]float64) (float64, error), vars map[string]Measurement,
+	corr *Correlation, samples int, rnd *rand.Rand) (Measurement, error) {
+	if samples < 2 {
+		return Measurement{}, errors.New("incorrect count of samples: " + strconv.Itoa(samples))
+	}
+	if err := validate(vars, corr); err != nil {
+		return Measurement{}, err
+	}
+	names := Uncertain(vars)
+	l, err := cholesky(covariance(names, vars, corr))
+	if err != nil {
+		return Measurement{}, err
+	}
+
+	values := make(map[string]float64, len(vars))
+	for name, m := range vars {
+		values[name] = m.Value
+	}
+	normal := make([]float64, len(names))
+	var mean, m2 float64
+	for n := 1; n <= samples; n++ {
+		for i := range normal {
+			normal[i] = rnd.NormFloat64()
+		}
+		for i, name := range names {
+			val := vars[name].Value
+			for k := 0; k <= i; k++ {
+				val += l[i][k] * normal[k]
+			}
+			values[name] = val
+		}
+		res, err := eval(values)
+		if err != nil {
+			return Measurement{}, err
+		}
+		// Welford's online algorithm
+		delta := res - mean
+		mean += delta / float64(n)
+		m2 += delta * (res - mean)
+	}
+	return Measurement{Value: mean, Sigma: math.Sqrt(m2 / float64(samples-1))}, nil
+}
@@ -0,0 +1,139 @@
+package uncertainty_test
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/domains/uncertainty"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+const float64EqualityThreshold = 1e-9
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) <= float64EqualityThreshold
+}
+
+func TestMeasurementString(t *testing.T) {
+	m := uncertainty.Measurement{Value: 9.81, Sigma: 0.02}
+	if m.String() != "9.81 ± 0.02" {
+		t.Error("incorrect string conversion = " + m.String())
+	}
+}
+
+func TestEvaluateUncertainty(t *testing.T) {
+	type TestVars map[string]uncertainty.Measurement
+	type TestData struct {
+		input  string
+		vars   TestVars
+		corr   *uncertainty.Correlation
+		output uncertainty.Measurement
+	}
+
+	xy := TestVars{"x": {Value: 10, Sigma: 0.3}, "y": {Value: 5, Sigma: 0.4}}
+	data := []TestData{
+		{"x + y", xy, nil, uncertainty.Measurement{Value: 15, Sigma: 0.5}},
+		{"x - y", xy, nil, uncertainty.Measurement{Value: 5, Sigma: 0.5}},
+		{"2 * x", xy, nil, uncertainty.Measurement{Value: 20, Sigma: 0.6}},
+		{"x * y", xy, nil, uncertainty.Measurement{Value: 50, Sigma: math.Sqrt(1.5*1.5 + 4*4)}},
+		{"x + y", xy, &uncertainty.Correlation{
+			Vars:   []string{"x", "y"},
+			Matrix: [][]float64{{1, 1}, {1, 1}},
+		}, uncertainty.Measurement{Value: 15, Sigma: 0.7}},
+		{"x - y", xy, &uncertainty.Correlation{
+			Vars:   []string{"y", "x"},
+			Matrix: [][]float64{{1, 0.5}, {0.5, 1}},
+		}, uncertainty.Measurement{Value: 5, Sigma: math.Sqrt(0.25 - 0.12)}},
+		{"sqrt(x * 10) + k", TestVars{"x": {Value: 10, Sigma: 0.3}, "k": {Value: 1}}, nil,
+			uncertainty.Measurement{Value: 11, Sigma: 0.15}},
+	}
+
+	p := parser.NewParser()
+	for _, d := range data {
+		_, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+		}
+		res, err := p.EvaluateUncertainty(d.vars, d.corr)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !almostEqual(res.Value, d.output.Value) || !almostEqual(res.Sigma, d.output.Sigma) {
+			t.Error("incorrect result of '" + d.input + "': " + res.String() + ", need: " + d.output.String())
+		}
+	}
+
+	// incorrect correlation matrices
+	incorrect := []*uncertainty.Correlation{
+		{Vars: []string{"x", "y"}, Matrix: [][]float64{{1, 0.5}}},
+		{Vars: []string{"x", "z"}, Matrix: [][]float64{{1, 0.5}, {0.5, 1}}},
+		{Vars: []string{"x", "y"}, Matrix: [][]float64{{1, 0.5}, {0.4, 1}}},
+		{Vars: []string{"x", "y"}, Matrix: [][]float64{{1, 2}, {2, 1}}},
+		{Vars: []string{"x", "y"}, Matrix: [][]float64{{0.5, 0}, {0, 1}}},
+		{Vars: []string{"x", "y"}, Matrix: [][]float64{{1, 0.5}, {}}},
+	}
+	p.Parse("x + y")
+	for i, corr := range incorrect {
+		if _, err := p.EvaluateUncertainty(xy, corr); err == nil {
+			t.Error("incorrect error handling of correlation matrix " + strconv.Itoa(i))
+		}
+	}
+}
+
+func TestEvaluateMonteCarlo(t *testing.T) {
+	const samples = 20000
+	vars := map[string]uncertainty.Measurement{"x": {Value: 10, Sigma: 0.3}, "y": {Value: 5, Sigma: 0.4}}
+
+	p := parser.NewParser()
+	p.Parse("x * y")
+	linear, err := p.EvaluateUncertainty(vars, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	res, err := p.EvaluateMonteCarlo(vars, nil, samples, 1)
+	if err != nil {
+		t.Error(err)
+	}
+	if math.Abs(res.Value-linear.Value) > 0.1 || math.Abs(res.Sigma-linear.Sigma) > 0.1 {
+		t.Error("incorrect Monte Carlo result: " + res.String() + ", linear: " + linear.String())
+	}
+
+	// fully anti-correlated variables
+	corr := &uncertainty.Correlation{Vars: []string{"x", "y"}, Matrix: [][]float64{{1, -1}, {-1, 1}}}
+	p.Parse("x + y")
+	res, err = p.EvaluateMonteCarlo(vars, corr, samples, 1)
+	if err != nil {
+		t.Error(err)
+	}
+	if math.Abs(res.Value-15) > 0.01 || math.Abs(res.Sigma-0.1) > 0.01 {
+		t.Error("incorrect Monte Carlo result: " + res.String())
+	}
+
+	// the same seed gives the same result
+	again, _ := p.EvaluateMonteCarlo(vars, corr, samples, 1)
+	if again != res {
+		t.Error("Monte Carlo result is not reproducible: " + again.String() + ", " + res.String())
+	}
+
+	if _, err := p.EvaluateMonteCarlo(vars, nil, 1, 1); err == nil {
+		t.Error("incorrect samples count error handling")
+	}
+	p.Parse("sqrt(x - 10)")
+	if _, err := p.EvaluateMonteCarlo(vars, nil, samples, 1); err == nil {
+		t.Error("incorrect evaluation error handling")
+	}
+
+	// standard deviations must be non-negative numbers
+	p.Parse("x + y")
+	for _, sigma := range []float64{-0.3, math.NaN(), math.Inf(1)} {
+		incorrect := map[string]uncertainty.Measurement{"x": {Value: 10, Sigma: sigma}, "y": {Value: 5, Sigma: 0.4}}
+		if _, err := p.EvaluateUncertainty(incorrect, nil); err == nil {
+			t.Error("incorrect error handling of sigma " + strconv.FormatFloat(sigma, 'g', -1, 64))
+		}
+		if _, err := p.EvaluateMonteCarlo(incorrect, nil, samples, 1); err == nil {
+			t.Error("incorrect Monte Carlo error handling of sigma " + strconv.FormatFloat(sigma, 'g', -1, 64))
+		}
+	}
+}
@@ -0,0 +1,468 @@
+package units
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/overseven/go-math-expression-parser/funcs"
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// count of SI base dimensions
+const baseDimensions = 7
+
+// Dimension - exponents of SI base units: m, kg, s, A, K, mol, cd
+type Dimension [baseDimensions]int
+
+var baseSymbols = [baseDimensions]string{"m", "kg", "s", "A", "K", "mol", "cd"}
+
+// IsDimensionless - checks that all exponents are zero
+func (d Dimension) IsDimensionless() bool {
+	return d == Dimension{}
+}
+
+func (d Dimension) add(other Dimension, sign int) Dimension {
+	for i := range d {
+		d[i] += sign * other[i]
+	}
+	return d
+}
+
+// String - base units representation like 'm*kg/s^2'
+func (d Dimension) String() string {
+	num, den := "", ""
+	for i, e := range d {
+		if e == 0 {
+			continue
+		}
+		part := baseSymbols[i]
+		if e > 1 || e < -1 {
+			part += "^" + strconv.Itoa(abs(e))
+		}
+		if e > 0 {
+			if num != "" {
+				num += "*"
+			}
+			num += part
+		} else {
+			den += "/" + part
+		}
+	}
+	if num == "" && den != "" {
+		num = "1"
+	}
+	return num + den
+}
+
+type unit struct {
+	scale      float64
+	dim        Dimension
+	prefixable bool
+}
+
+var prefixes = map[string]float64{
+	"G": 1e9,
+	"M": 1e6,
+	"k": 1e3,
+	"c": 1e-2,
+	"m": 1e-3,
+	"u": 1e-6,
+	"µ": 1e-6,
+	"n": 1e-9,
+}
+
+var knownUnits = map[string]unit{
+	// base units
+	"m":   {1, Dimension{1, 0, 0, 0, 0, 0, 0}, true},
+	"g":   {1e-3, Dimension{0, 1, 0, 0, 0, 0, 0}, true},
+	"s":   {1, Dimension{0, 0, 1, 0, 0, 0, 0}, true},
+	"A":   {1, Dimension{0, 0, 0, 1, 0, 0, 0}, true},
+	"K":   {1, Dimension{0, 0, 0, 0, 1, 0, 0}, false},
+	"mol": {1, Dimension{0, 0, 0, 0, 0, 1, 0}, true},
+	"cd":  {1, Dimension{0, 0, 0, 0, 0, 0, 1}, false},
+	// derived units
+	"Hz":  {1, Dimension{0, 0, -1, 0, 0, 0, 0}, true},
+	"N":   {1, Dimension{1, 1, -2, 0, 0, 0, 0}, true},
+	"Pa":  {1, Dimension{-1, 1, -2, 0, 0, 0, 0}, true},
+	"J":   {1, Dimension{2, 1, -2, 0, 0, 0, 0}, true},
+	"W":   {1, Dimension{2, 1, -3, 0, 0, 0, 0}, true},
+	"C":   {1, Dimension{0, 0, 1, 1, 0, 0, 0}, true},
+	"V":   {1, Dimension{2, 1, -3, -1, 0, 0, 0}, true},
+	"Ohm": {1, Dimension{2, 1, -3, -2, 0, 0, 0}, true},
+	"L":   {1e-3, Dimension{3, 0, 0, 0, 0, 0, 0}, true},
+	// non-SI units
+	"min": {60, Dimension{0, 0, 1, 0, 0, 0, 0}, false},
+	"h":   {3600, Dimension{0, 0, 1, 0, 0, 0, 0}, false},
+	"d":   {86400, Dimension{0, 0, 1, 0, 0, 0, 0}, false},
+	"t":   {1e3, Dimension{0, 1, 0, 0, 0, 0, 0}, false},
+	"in":  {0.0254, Dimension{1, 0, 0, 0, 0, 0, 0}, false},
+	"ft":  {0.3048, Dimension{1, 0, 0, 0, 0, 0, 0}, false},
+	"mi":  {1609.344, Dimension{1, 0, 0, 0, 0, 0, 0}, false},
+	"lb":  {0.45359237, Dimension{0, 1, 0, 0, 0, 0, 0}, false},
+}
+
+func lookupUnit(sym string) (unit, bool) {
+	if u, ok := knownUnits[sym]; ok {
+		return u, true
+	}
+	for p, scale := range prefixes {
+		if !strings.HasPrefix(sym, p) {
+			continue
+		}
+		if u, ok := knownUnits[sym[len(p):]]; ok && u.prefixable {
+			u.scale *= scale
+			return u, true
+		}
+	}
+	return unit{}, false
+}
+
+// ParseUnit - parse unit expression like 'km/h' or 'kg*m^2/s^2', return its scale relative to SI base units
+func ParseUnit(str string) (scale float64, dim Dimension, err error) {
+	scale = 1
+	runes := []rune(strings.TrimSpace(str))
+	if len(runes) == 0 {
+		return 0, dim, errors.New("empty unit")
+	}
+	sign := 1
+	i := 0
+	for i < len(runes) {
+		start := i
+		for i < len(runes) && unicode.IsLetter(runes[i]) {
+			i++
+		}
+		sym := string(runes[start:i])
+		u, ok := lookupUnit(sym)
+		if !ok {
+			return 0, dim, errors.New("unknown unit: '" + sym + "'")
+		}
+		power := 1
+		if i < len(runes) && runes[i] == '^' {
+			i++
+			start = i
+			if i < len(runes) && runes[i] == '-' {
+				i++
+			}
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			power, err = strconv.Atoi(string(runes[start:i]))
+			if err != nil {
+				return 0, dim, errors.New("incorrect power of unit '" + sym + "'")
+			}
+		}
+		scale *= math.Pow(u.scale, float64(sign*power))
+		for k := 0; k < sign*power; k++ {
+			dim = dim.add(u.dim, 1)
+		}
+		for k := 0; k > sign*power; k-- {
+			dim = dim.add(u.dim, -1)
+		}
+		for i < len(runes) && unicode.IsSpace(runes[i]) {
+			i++
+		}
+		if i == len(runes) {
+			break
+		}
+		switch runes[i] {
+		case '*', '·':
+			sign = 1
+		case '/':
+			sign = -1
+		default:
+			return 0, dim, errors.New("incorrect symbol in unit: '" + string(runes[i]) + "'")
+		}
+		i++
+		for i < len(runes) && unicode.IsSpace(runes[i]) {
+			i++
+		}
+	}
+	return scale, dim, nil
+}
+
+// Quantity - a number with physical dimension. Value is stored in SI base units
+type Quantity struct {
+	Value float64
+	Dim   Dimension
+	// Unit - preferred unit of string representation, base units are used if empty
+	Unit string
+}
+
+// New - create a quantity from the value measured in the unit
+func New(val float64, unitStr string) (Quantity, error) {
+	if unitStr == "" {
+		return Quantity{Value: val}, nil
+	}
+	scale, dim, err := ParseUnit(unitStr)
+	if err != nil {
+		return Quantity{}, err
+	}
+	return Quantity{Value: val * scale, Dim: dim, Unit: unitStr}, nil
+}
+
+// Parse - parse quantity like '5 km/h' or '2.5m'
+func Parse(str string) (Quantity, error) {
+	str = strings.TrimSpace(str)
+	numEnd := 0
+	if str != "" && (unicode.IsDigit(rune(str[0])) || str[0] == '.') {
+		for i := len(str); i > 0; i-- {
+			if _, err := strconv.ParseFloat(str[:i], 64); err == nil {
+				numEnd = i
+				break
+			}
+		}
+	}
+	val := 1.0
+	if numEnd > 0 {
+		val, _ = strconv.ParseFloat(str[:numEnd], 64)
+	}
+	unitStr := strings.TrimSpace(str[numEnd:])
+	if numEnd == 0 && unitStr == "" {
+		return Quantity{}, errors.New("incorrect quantity: '" + str + "'")
+	}
+	return New(val, unitStr)
+}
+
+// In - return the value of quantity measured in the unit
+func (q Quantity) In(unitStr string) (float64, error) {
+	scale, dim, err := ParseUnit(unitStr)
+	if err != nil {
+		return 0, err
+	}
+	if dim != q.Dim {
+		return 0, errors.New("can't convert '" + q.Dim.String() + "' to '" + unitStr + "'")
+	}
+	return q.Value / scale, nil
+}
+
+// toString conversation
+func (q Quantity) String() string {
+	val, unitStr := q.Value, q.Dim.String()
+	if q.Unit != "" {
+		if v, err := q.In(q.Unit); err == nil {
+			val, unitStr = v, q.Unit
+		}
+	}
+	str := strconv.FormatFloat(val, 'g', -1, 64)
+	if unitStr != "" {
+		str += " " + unitStr
+	}
+	return str
+}
+
+// Domain - evaluation of an expression over quantities with units
+type Domain struct {
+	functions [funcs.LevelsOfOperators]map[string]funcs.FuncType
+}
+
+// NewDomain - create a Domain, which falls back to the functions for dimensionless values
+func NewDomain(functions [funcs.LevelsOfOperators]map[string]funcs.FuncType) *Domain {
+	return &Domain{functions: functions}
+}
+
+func quantity(v interfaces.Value) (Quantity, error) {
+	q, ok := v.(Quantity)
+	if !ok {
+		return Quantity{}, errors.New("value is not a quantity")
+	}
+	return q, nil
+}
+
+// Const - dimensionless number
+func (d *Domain) Const(val float64) (interfaces.Value, error) {
+	return Quantity{Value: val}, nil
+}
+
+// Literal - quantity like '5km', unit like 'm' or unit string like '"km/h"'
+func (d *Domain) Literal(term string) (interfaces.Value, error) {
+	if internal.IsQuoted(term) {
+		return New(1, term[1:len(term)-1])
+	}
+	q, err := Parse(term)
+	if err != nil {
+		return nil, errors.New("value '" + term + "' is neither a variable nor a quantity")
+	}
+	return q, nil
+}
+
+func (d *Domain) binaryFunc(op string) (funcs.FuncType, error) {
+	for i := 1; i < funcs.LevelsOfOperators; i++ {
+		if f, ok := d.functions[i][op]; ok {
+			return f, nil
+		}
+	}
+	return nil, errors.New("not supported binary operation: '" + op + "'")
+}
+
+// Binary - binary operator with dimensional analysis
+func (d *Domain) Binary(op string, x, y interfaces.Value) (interfaces.Value, error) {
+	a, err := quantity(x)
+	if err != nil {
+		return nil, err
+	}
+	b, err := quantity(y)
+	if err != nil {
+		return nil, err
+	}
+	f, err := d.binaryFunc(op)
+	if err != nil {
+		return nil, err
+	}
+	res := Quantity{}
+	switch op {
+	case "+", "-", "%":
+		if a.Dim != b.Dim {
+			return nil, errors.New("incompatible dimensions for '" + op + "': '" + a.Dim.String() + "' and '" + b.Dim.String() + "'")
+		}
+		res.Dim, res.Unit = a.Dim, a.Unit
+		if res.Unit == "" {
+			res.Unit = b.Unit
+		}
+	case "<", "<=", ">", ">=", "==", "!=":
+		if a.Dim != b.Dim {
+			return nil, errors.New("incompatible dimensions for '" + op + "': '" + a.Dim.String() + "' and '" + b.Dim.String() + "'")
+		}
+	case "*":
+		res.Dim = a.Dim.add(b.Dim, 1)
+		if b.Dim.IsDimensionless() {
+			res.Unit = a.Unit
+		} else if a.Dim.IsDimensionless() {
+			res.Unit = b.Unit
+		}
+	case "/":
+		res.Dim = a.Dim.add(b.Dim, -1)
+		if b.Dim.IsDimensionless() {
+			res.Unit = a.Unit
+		}
+	case "^":
+		if !b.Dim.IsDimensionless() {
+			return nil, errors.New("exponent must be dimensionless, but get: '" + b.Dim.String() + "'")
+		}
+		for i, e := range a.Dim {
+			p := float64(e) * b.Value
+			if p != math.Trunc(p) {
+				return nil, errors.New("power " + strconv.FormatFloat(b.Value, 'g', -1, 64) + " of '" + a.Dim.String() + "' has fractional dimension")
+			}
+			res.Dim[i] = int(p)
+		}
+	default:
+		if !a.Dim.IsDimensionless() || !b.Dim.IsDimensionless() {
+			return nil, errors.New("operation '" + op + "' is supported only for dimensionless values")
+		}
+	}
+	res.Value, err = f(a.Value, b.Value)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Unary - unary operator, only '+' and '-' are allowed for dimensional values
+func (d *Domain) Unary(op string, x interfaces.Value) (interfaces.Value, error) {
+	q, err := quantity(x)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := d.functions[0][op]
+	if !ok {
+		return nil, errors.New("not supported unary operation: '" + op + "'")
+	}
+	if op != "+" && op != "-" && !q.Dim.IsDimensionless() {
+		return nil, errors.New("operation '" + op + "' is supported only for dimensionless values")
+	}
+	q.Value, err = f(q.Value)
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Call - unit-aware 'sqrt', 'abs' and 'to' functions, other functions accept only dimensionless values
+func (d *Domain) Call(name string, args ...interfaces.Value) (interfaces.Value, error) {
+	qs := make([]Quantity, len(args))
+	for i, arg := range args {
+		q, err := quantity(arg)
+		if err != nil {
+			return nil, err
+		}
+		qs[i] = q
+	}
+	if name == "to" {
+		return convert(qs...)
+	}
+	f, ok := d.functions[0][name]
+	if !ok {
+		return nil, errors.New("function '" + name + "' is not supported")
+	}
+	res := Quantity{}
+	switch name {
+	case "sqrt":
+		if len(qs) != 1 {
+			return nil, errors.New("incorrect count of args for 'sqrt' function. Need: 1, but get: " + strconv.Itoa(len(qs)))
+		}
+		for i, e := range qs[0].Dim {
+			if e%2 != 0 {
+				return nil, errors.New("'sqrt' of '" + qs[0].Dim.String() + "' has fractional dimension")
+			}
+			res.Dim[i] = e / 2
+		}
+	case "abs":
+		if len(qs) == 1 {
+			res.Dim, res.Unit = qs[0].Dim, qs[0].Unit
+		}
+	default:
+		for _, q := range qs {
+			if !q.Dim.IsDimensionless() {
+				return nil, errors.New("function '" + name + "' accepts only dimensionless values")
+			}
+		}
+	}
+	vals := make([]float64, len(qs))
+	for i, q := range qs {
+		vals[i] = q.Value
+	}
+	val, err := f(vals...)
+	if err != nil {
+		return nil, err
+	}
+	res.Value = val
+	return res, nil
+}
+
+// Truth - non-zero value is true
+func (d *Domain) Truth(cond interfaces.Value) (bool, bool, error) {
+	q, err := quantity(cond)
+	if err != nil {
+		return false, false, err
+	}
+	return q.Value != 0, true, nil
+}
+
+// Join - the truth of quantity is always known, so the values are never joined
+func (d *Domain) Join(x, y interfaces.Value) (interfaces.Value, error) {
+	return nil, errors.New("quantities can't be joined")
+}
+
+// convert - implementation of 'to(quantity, "unit")' function
+func convert(args ...Quantity) (Quantity, error) {
+	if len(args) != 2 {
+		return Quantity{}, errors.New("incorrect count of args for 'to' function. Need: 2, but get: " + strconv.Itoa(len(args)))
+	}
+	q, target := args[0], args[1]
+	if q.Dim != target.Dim {
+		return Quantity{}, errors.New("can't convert '" + q.Dim.String() + "' to '" + target.Dim.String() + "'")
+	}
+	q.Unit = target.Unit
+	return q, nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
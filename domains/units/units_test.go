@@ -0,0 +1,127 @@
+package units_test
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/domains/units"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+const float64EqualityThreshold = 1e-9
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) <= float64EqualityThreshold
+}
+
+func TestParseUnit(t *testing.T) {
+	type TestData struct {
+		input string
+		scale float64
+		dim   units.Dimension
+	}
+	data := []TestData{
+		{"m", 1, units.Dimension{1, 0, 0, 0, 0, 0, 0}},
+		{"km/h", 1000.0 / 3600.0, units.Dimension{1, 0, -1, 0, 0, 0, 0}},
+		{"kg*m^2/s^2", 1, units.Dimension{2, 1, -2, 0, 0, 0, 0}},
+		{"mm", 1e-3, units.Dimension{1, 0, 0, 0, 0, 0, 0}},
+		{"m / s / s", 1, units.Dimension{1, 0, -2, 0, 0, 0, 0}},
+		{"kPa", 1e3, units.Dimension{-1, 1, -2, 0, 0, 0, 0}},
+	}
+	for _, d := range data {
+		scale, dim, err := units.ParseUnit(d.input)
+		if err != nil {
+			t.Error(err)
+		}
+		if !almostEqual(scale, d.scale) || dim != d.dim {
+			t.Error("incorrect unit '" + d.input + "': " + strconv.FormatFloat(scale, 'e', 4, 64) + " " + dim.String())
+		}
+	}
+
+	for _, s := range []string{"", "parsec", "m^x", "m+s"} {
+		if _, _, err := units.ParseUnit(s); err == nil {
+			t.Error("incorrect error handling of '" + s + "'")
+		}
+	}
+}
+
+func TestQuantityString(t *testing.T) {
+	q, _ := units.New(2.5, "m/s")
+	if q.String() != "2.5 m/s" {
+		t.Error("incorrect string conversion = " + q.String())
+	}
+	q.Unit = ""
+	q.Dim = units.Dimension{2, 1, -2, 0, 0, 0, 0}
+	if q.String() != "2.5 m^2*kg/s^2" {
+		t.Error("incorrect string conversion = " + q.String())
+	}
+	q = units.Quantity{Value: 4, Dim: units.Dimension{0, 0, -1, 0, 0, 0, 0}}
+	if q.String() != "4 1/s" {
+		t.Error("incorrect string conversion = " + q.String())
+	}
+}
+
+func TestEvaluateUnits(t *testing.T) {
+	type TestVars map[string]units.Quantity
+	type TestData struct {
+		input  string
+		vars   TestVars
+		output float64
+		unit   string
+	}
+
+	speed, _ := units.New(36, "km/h")
+	area, _ := units.New(16, "m^2")
+	data := []TestData{
+		{"5 m / 2 s", TestVars{}, 2.5, "m/s"},
+		{"3 km + 200 m", TestVars{}, 3.2, "km"},
+		{"to(v, \"m/s\")", TestVars{"v": speed}, 10, "m/s"},
+		{"to(v * 30 min, \"km\")", TestVars{"v": speed}, 18, "km"},
+		{"sqrt(a)", TestVars{"a": area}, 4, "m"},
+		{"abs(-2 kg) * 3", TestVars{}, 6, "kg"},
+		{"(2 m)^2 / 4", TestVars{}, 1, "m^2"},
+		{"10 * 2", TestVars{}, 20, ""},
+		{"to(12 in, \"cm\")", TestVars{}, 30.48, "cm"},
+		{"9.81 m/s^2", TestVars{}, 9.81, "m/s^2"},
+		{"sqrt(4 m^2)", TestVars{}, 2, "m"},
+		{"5 kg * 9.81 m/s^2", TestVars{}, 49.05, "kg*m/s^2"},
+		{"4 m² / 2 m", TestVars{}, 2, "m"},
+		{"3 kg*m^2 * 2 s^-2", TestVars{}, 6, "kg*m^2/s^2"},
+		{"10 kg / 2 m", TestVars{}, 5, "kg/m"},
+	}
+
+	p := parser.NewParser()
+	for _, d := range data {
+		_, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+		}
+		res, err := p.EvaluateUnits(d.vars)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		val := res.Value
+		if d.unit != "" {
+			val, err = res.In(d.unit)
+			if err != nil {
+				t.Error(err)
+			}
+		}
+		if !almostEqual(val, d.output) {
+			t.Error("incorrect result of '" + d.input + "': " + res.String())
+		}
+	}
+
+	// errors of dimensional analysis
+	for _, input := range []string{"3 m + 2 s", "sqrt(3 m)", "2 ^ (1 s)", "to(3 m, \"kg\")", "5 parsec", "x"} {
+		_, err := p.Parse(input)
+		if err != nil {
+			t.Error(err)
+		}
+		if _, err := p.EvaluateUnits(TestVars{}); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package compact
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/overseven/go-math-expression-parser/funcs/userfunc"
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// Version - version of the binary format, it's written after the magic bytes
+const Version = 1
+
+// MaxDepth - limit of nesting of the decoded tree
+const MaxDepth = 1000
+
+// magic - first bytes of the encoded tree
+var magic = []byte("MEXP")
+
+// size of the header: magic and version, and size of the checksum at the end
+const (
+	headerSize   = 5
+	checksumSize = 4
+)
+
+// tags of the nodes
+const (
+	tagEmpty byte = iota
+	tagNumber
+	tagString
+	tagVariable
+	tagBinary
+	tagUnary
+	tagCall
+	tagLet
+	tagAssign
+	tagScript
+)
+
+// Encode - binary form of the expression tree:
+// header "MEXP" and version, constant pool of numbers and strings, table of identifiers,
+// the nodes in prefix order with varint indexes in the tables and CRC-32 of all previous bytes
+func Encode(exp interfaces.Expression) ([]byte, error) {
+	e := encoder{constIndex: make(map[string]int), identIndex: make(map[string]int)}
+	if err := e.node(exp); err != nil {
+		return nil, err
+	}
+
+	buf := append([]byte{}, magic...)
+	buf = append(buf, Version)
+	buf = appendTable(buf, e.consts)
+	buf = appendTable(buf, e.idents)
+	buf = append(buf, e.nodes...)
+	return binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf)), nil
+}
+
+type encoder struct {
+	consts     []string
+	constIndex map[string]int
+	idents     []string
+	identIndex map[string]int
+	nodes      []byte
+}
+
+func appendTable(buf []byte, table []string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(table)))
+	for _, str := range table {
+		buf = binary.AppendUvarint(buf, uint64(len(str)))
+		buf = append(buf, str...)
+	}
+	return buf
+}
+
+// intern - index of the string in the table, the string is added at the first use
+func intern(str string, table *[]string, index map[string]int) uint64 {
+	i, ok := index[str]
+	if !ok {
+		i = len(*table)
+		*table = append(*table, str)
+		index[str] = i
+	}
+	return uint64(i)
+}
+
+func (e *encoder) constant(tag byte, str string) {
+	e.nodes = append(e.nodes, tag)
+	e.nodes = binary.AppendUvarint(e.nodes, intern(str, &e.consts, e.constIndex))
+}
+
+func (e *encoder) ident(tag byte, str string) {
+	e.nodes = append(e.nodes, tag)
+	e.nodes = binary.AppendUvarint(e.nodes, intern(str, &e.idents, e.identIndex))
+}
+
+func (e *encoder) node(exp interfaces.Expression) error {
+	switch n := exp.(type) {
+	case *internal.Term:
+		switch _, err := strconv.ParseFloat(n.Val, 64); {
+		case n.Val == "":
+			e.nodes = append(e.nodes, tagEmpty)
+		case err == nil:
+			e.constant(tagNumber, n.Val)
+		case internal.IsQuoted(n.Val):
+			e.constant(tagString, n.Val[1:len(n.Val)-1])
+		default:
+			e.ident(tagVariable, n.Val)
+		}
+		return nil
+
+	case *internal.Node:
+		e.ident(tagBinary, n.Op)
+		if err := e.node(n.LExp); err != nil {
+			return err
+		}
+		return e.node(n.RExp)
+
+	case *internal.Unary:
+		e.ident(tagUnary, n.Op)
+		return e.node(n.Exp)
+
+	case interfaces.Function:
+		e.ident(tagCall, n.GetOperation())
+		return e.list(n.GetArgs())
+
+	case *internal.Let:
+		e.ident(tagLet, n.Name)
+		if err := e.node(n.Value); err != nil {
+			return err
+		}
+		return e.node(n.Body)
+
+	case *internal.Assign:
+		e.ident(tagAssign, n.Name)
+		return e.node(n.Exp)
+
+	case *internal.Script:
+		e.nodes = append(e.nodes, tagScript)
+		return e.list(n.Stmts)
+	}
+	return errors.New("not supported node '" + exp.String() + "' in binary format")
+}
+
+func (e *encoder) list(exps []interfaces.Expression) error {
+	e.nodes = binary.AppendUvarint(e.nodes, uint64(len(exps)))
+	for _, exp := range exps {
+		if err := e.node(exp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode - the expression tree of the binary form.
+// The header, the checksum, the sizes, the indexes and the nesting are checked before use
+func Decode(data []byte) (interfaces.Expression, error) {
+	if len(data) < headerSize+checksumSize {
+		return nil, errors.New("truncated binary expression")
+	}
+	if string(data[:len(magic)]) != string(magic) {
+		return nil, errors.New("incorrect header of binary expression")
+	}
+	if data[len(magic)] != Version {
+		return nil, errors.New("not supported version " + strconv.Itoa(int(data[len(magic)])) +
+			" of binary expression, need: " + strconv.Itoa(Version))
+	}
+	body := data[:len(data)-checksumSize]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(data[len(body):]) {
+		return nil, errors.New("incorrect checksum of binary expression")
+	}
+
+	d := decoder{data: body, pos: headerSize}
+	var err error
+	if d.consts, err = d.table(); err != nil {
+		return nil, err
+	}
+	if d.idents, err = d.table(); err != nil {
+		return nil, err
+	}
+	exp, err := d.node(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, errors.New("unexpected data at " + strconv.Itoa(d.pos) + " position of binary expression")
+	}
+	return exp, nil
+}
+
+type decoder struct {
+	data   []byte
+	pos    int
+	consts []string
+	idents []string
+}
+
+func (d *decoder) corrupted() error {
+	return errors.New("corrupted binary expression at " + strconv.Itoa(d.pos) + " position")
+}
+
+// uvarint - the number, which isn't greater than the limit
+func (d *decoder) uvarint(limit int) (int, error) {
+	val, n := binary.Uvarint(d.data[d.pos:])
+	if n <= 0 || val > uint64(limit) {
+		return 0, d.corrupted()
+	}
+	d.pos += n
+	return int(val), nil
+}
+
+// count - size of the list, each element takes one byte at least
+func (d *decoder) count() (int, error) {
+	return d.uvarint(len(d.data) - d.pos)
+}
+
+func (d *decoder) table() ([]string, error) {
+	size, err := d.count()
+	if err != nil {
+		return nil, err
+	}
+	table := make([]string, size)
+	for i := range table {
+		length, err := d.uvarint(len(d.data) - d.pos)
+		if err != nil {
+			return nil, err
+		}
+		if length > len(d.data)-d.pos {
+			return nil, d.corrupted()
+		}
+		str := d.data[d.pos : d.pos+length]
+		if !utf8.Valid(str) {
+			return nil, d.corrupted()
+		}
+		table[i] = string(str)
+		d.pos += length
+	}
+	return table, nil
+}
+
+// index - the string of the table by the index
+func (d *decoder) index(table []string) (string, error) {
+	if len(table) == 0 {
+		return "", d.corrupted()
+	}
+	i, err := d.uvarint(len(table) - 1)
+	if err != nil {
+		return "", err
+	}
+	return table[i], nil
+}
+
+// name - the identifier, which can't be empty
+func (d *decoder) name() (string, error) {
+	name, err := d.index(d.idents)
+	if err == nil && name == "" {
+		return "", d.corrupted()
+	}
+	return name, err
+}
+
+func (d *decoder) node(depth int) (interfaces.Expression, error) {
+	if depth > MaxDepth {
+		return nil, errors.New("nesting of binary expression is deeper than " + strconv.Itoa(MaxDepth))
+	}
+	if d.pos >= len(d.data) {
+		return nil, errors.New("truncated binary expression")
+	}
+	tag := d.data[d.pos]
+	d.pos++
+
+	switch tag {
+	case tagEmpty:
+		return &internal.Term{}, nil
+
+	case tagNumber:
+		val, err := d.index(d.consts)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := strconv.ParseFloat(val, 64); err != nil {
+			return nil, errors.New("incorrect number '" + val + "' in binary expression")
+		}
+		return &internal.Term{Val: val}, nil
+
+	case tagString:
+		val, err := d.index(d.consts)
+		if err != nil {
+			return nil, err
+		}
+		return &internal.Term{Val: "\"" + val + "\""}, nil
+
+	case tagVariable:
+		name, err := d.name()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := strconv.ParseFloat(name, 64); err == nil || internal.IsQuoted(name) {
+			return nil, errors.New("incorrect variable name '" + name + "' in binary expression")
+		}
+		return &internal.Term{Val: name}, nil
+
+	case tagBinary:
+		op, err := d.name()
+		if err != nil {
+			return nil, err
+		}
+		left, err := d.node(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		right, err := d.node(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &internal.Node{Op: op, LExp: left, RExp: right}, nil
+
+	case tagUnary:
+		op, err := d.name()
+		if err != nil {
+			return nil, err
+		}
+		exp, err := d.node(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &internal.Unary{Op: op, Exp: exp}, nil
+
+	case tagCall:
+		name, err := d.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := d.list(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &userfunc.Func{Op: name, Args: args}, nil
+
+	case tagLet:
+		name, err := d.name()
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.node(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		body, err := d.node(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &internal.Let{Name: name, Value: value, Body: body}, nil
+
+	case tagAssign:
+		name, err := d.name()
+		if err != nil {
+			return nil, err
+		}
+		exp, err := d.node(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &internal.Assign{Name: name, Exp: exp}, nil
+
+	case tagScript:
+		stmts, err := d.list(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(stmts) == 0 {
+			return nil, errors.New("script without statements in binary expression")
+		}
+		return &internal.Script{Stmts: stmts}, nil
+	}
+	d.pos--
+	return nil, errors.New("unknown node tag " + strconv.Itoa(int(tag)) + " at " + strconv.Itoa(d.pos) +
+		" position of binary expression")
+}
+
+func (d *decoder) list(depth int) ([]interfaces.Expression, error) {
+	size, err := d.count()
+	if err != nil {
+		return nil, err
+	}
+	exps := make([]interfaces.Expression, size)
+	for i := range exps {
+		if exps[i], err = d.node(depth); err != nil {
+			return nil, err
+		}
+	}
+	return exps, nil
+}
@@ -0,0 +1,122 @@
+package compact_test
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"strconv"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/formats/compact"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+var corpus = []string{
+	"", "x", "x*(sqrt(y)+1)", "(доход-расход)*налог", "f1(1) + f1(x, 2) * f1()", "2^3-10", "100+sqrt(3^2+(2*2+3))",
+	"tax = price * 0.2; total = price + tax; total * qty", "let m = price - cost in m * qty / (m + fee)",
+	"if(x > 0, sqrt(x), 0)", "piecewise(x < 0, -1, x == 0, 0, 1)", "to(d, \"km / h\")", "1.50e-3 * x - -x",
+}
+
+func TestRoundTrip(t *testing.T) {
+	p := parser.NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) { return 1, nil }, "f1")
+	for _, input := range corpus {
+		exp, err := p.Parse(input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		data, err := compact.Encode(exp)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		decoded, err := compact.Decode(data)
+		if err != nil {
+			t.Error("can't decode '" + input + "': " + err.Error())
+			continue
+		}
+		if decoded.String() != exp.String() {
+			t.Error("incorrect decoding of '" + input + "': " + decoded.String())
+		}
+	}
+
+	// identifiers and constants are stored once
+	exp, _ := p.Parse("price * 0.2 + price * 0.2 + price * 0.2 + price * 0.2")
+	data, _ := compact.Encode(exp)
+	json, _ := p.MarshalExpression(exp)
+	if len(data)*8 > len(json) {
+		t.Error("binary form isn't compact: " + strconv.Itoa(len(data)) + " bytes")
+	}
+}
+
+func TestCorruptedInput(t *testing.T) {
+	p := parser.NewParser()
+	exp, _ := p.Parse("let m = price - cost in m * qty / sqrt(m + 1.5)")
+	data, _ := compact.Encode(exp)
+
+	for i := 0; i < len(data); i++ {
+		if _, err := compact.Decode(data[:i]); err == nil {
+			t.Error("truncated data of " + strconv.Itoa(i) + " bytes is decoded")
+		}
+		changed := append([]byte{}, data...)
+		changed[i] ^= 0x10
+		if _, err := compact.Decode(changed); err == nil {
+			t.Error("data with changed byte " + strconv.Itoa(i) + " is decoded")
+		}
+	}
+
+	// seal - header and checksum of the crafted tables and nodes
+	seal := func(body ...byte) []byte {
+		data := append([]byte("MEXP\x01"), body...)
+		return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+	}
+	deep := []byte{0, 1, 1, '-'}
+	for i := 0; i <= compact.MaxDepth; i++ {
+		deep = append(deep, 5, 0)
+	}
+	deep = append(deep, 0)
+
+	type TestData struct {
+		name  string
+		input []byte
+	}
+	crafted := []TestData{
+		{"version", append([]byte("MEXP\x02"), seal(0, 0, 0)[5:]...)},
+		{"huge table", seal(0xff, 0xff, 0xff, 0xff, 0x0f, 0, 0)},
+		{"huge string", seal(1, 0xff, 0xff, 0x03, 'a', 0, 0)},
+		{"varint overflow", seal(0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01)},
+		{"constant index", seal(1, 1, '1', 0, 1, 1)},
+		{"empty table index", seal(0, 0, 3, 0)},
+		{"identifier index", seal(0, 1, 1, 'x', 3, 1)},
+		{"huge list", seal(0, 1, 1, 'f', 6, 0, 0xff, 0xff, 0x7f)},
+		{"unknown tag", seal(0, 0, 42)},
+		{"trailing data", seal(0, 0, 0, 0)},
+		{"missing operand", seal(0, 1, 1, '+', 4, 0, 0)},
+		{"invalid UTF-8", seal(0, 1, 2, 0xc3, 0x28, 3, 0)},
+		{"incorrect number", seal(1, 1, 'x', 0, 1, 0)},
+		{"numeric variable", seal(0, 1, 1, '1', 3, 0)},
+		{"empty name", seal(0, 1, 0, 3, 0)},
+		{"empty script", seal(0, 0, 9, 0)},
+		{"deep nesting", seal(deep...)},
+	}
+	for _, d := range crafted {
+		if _, err := compact.Decode(d.input); err == nil {
+			t.Error("incorrect error handling of " + d.name)
+		}
+	}
+	if exp, err := compact.Decode(seal(1, 1, '2', 0, 1, 0)); err != nil || exp.String() != "2" {
+		t.Error("can't decode crafted data")
+	}
+
+	// decoded tree is checked against the functions of the parser
+	exp, _ = p.Parse("sqrt(x)")
+	data, _ = compact.Encode(exp)
+	if _, err := parser.NewParser().DecodeBinary(data); err != nil {
+		t.Error(err)
+	}
+	other := parser.NewParser()
+	delete(other.Operators[0], "sqrt")
+	if _, err := other.DecodeBinary(data); err == nil {
+		t.Error("unknown function is decoded")
+	}
+}
@@ -0,0 +1,628 @@
+package gocode
+
+import (
+	"errors"
+	"go/format"
+	"go/token"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// Options - the generated file
+type Options struct {
+	// Package - name of the package of the file
+	Package string
+	// Func - name of the function
+	Func string
+	// Params - variables of the expression in the order of parameters
+	Params []string
+	// Source - text of the expression for the doc comment
+	Source string
+}
+
+// Go precedence of the operators, primary expressions bind tighter than any operator
+const (
+	precSum     = 4
+	precProduct = 5
+	precPrimary = 6
+)
+
+// code - Go expression and its precedence
+type code struct {
+	str  string
+	prec int
+}
+
+// names, which can't be used for variables
+var reserved = map[string]bool{"math": true, "errors": true, "fmt": true, "err": true, "float64": true}
+
+type generator struct {
+	lines   []string
+	temps   int
+	imports map[string]bool
+	// Go identifiers of the variables and the variables of the identifiers
+	names  map[string]string
+	owners map[string]string
+	params map[string]bool
+	// variables of the assignments
+	declared map[string]bool
+}
+
+// Generate - gofmt'd Go file with the function of the expression: func Total(price, qty float64) (float64, error).
+// Constants are inlined and folded, built-in functions are mapped to math calls, errors of the evaluation
+// (division by zero, negative root, ...) are returned like the evaluator does
+func Generate(exp interfaces.Expression, opts Options) ([]byte, error) {
+	if !token.IsIdentifier(opts.Package) || !token.IsIdentifier(opts.Func) {
+		return nil, errors.New("incorrect name of the package or the function")
+	}
+	g := generator{imports: make(map[string]bool), names: make(map[string]string), owners: make(map[string]string),
+		params: make(map[string]bool), declared: make(map[string]bool)}
+	params := make([]string, len(opts.Params))
+	for i, param := range opts.Params {
+		name, err := g.name(param)
+		if err != nil {
+			return nil, err
+		}
+		params[i] = name
+		g.params[param] = true
+	}
+
+	var res code
+	var err error
+	if s, ok := exp.(*internal.Script); ok {
+		res, err = g.script(s)
+	} else {
+		res, err = g.expr(exp)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("// Code generated by go-math-expression-parser; DO NOT EDIT.\n\n")
+	sb.WriteString("package " + opts.Package + "\n\n")
+	var imports []string
+	for pkg := range g.imports {
+		imports = append(imports, strconv.Quote(pkg))
+	}
+	sort.Strings(imports)
+	if len(imports) > 0 {
+		sb.WriteString("import (\n" + strings.Join(imports, "\n") + "\n)\n\n")
+	}
+	if opts.Source != "" {
+		sb.WriteString("// " + opts.Func + " - " + strings.ReplaceAll(opts.Source, "\n", "; ") + "\n")
+	}
+	sig := ""
+	if len(params) > 0 {
+		sig = strings.Join(params, ", ") + " float64"
+	}
+	sb.WriteString("func " + opts.Func + "(" + sig + ") (float64, error) {\n")
+	for _, line := range g.lines {
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("return " + res.str + ", nil\n}\n")
+	return format.Source([]byte(sb.String()))
+}
+
+// name - Go identifier of the variable. Dots are replaced by '_', keywords get the suffix '_'
+func (g *generator) name(variable string) (string, error) {
+	if name, ok := g.names[variable]; ok {
+		return name, nil
+	}
+	var sb strings.Builder
+	for _, c := range variable {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	name := sb.String()
+	switch {
+	case name == "" || unicode.IsDigit([]rune(name)[0]) || strings.HasPrefix(name, "_"):
+		name = "v" + name
+	case token.IsKeyword(name) || reserved[name]:
+		name += "_"
+	}
+	if owner, ok := g.owners[name]; ok && owner != variable {
+		return "", errors.New("variables '" + owner + "' and '" + variable + "' have the same Go name '" + name + "'")
+	}
+	g.names[variable] = name
+	g.owners[name] = variable
+	return name, nil
+}
+
+func (g *generator) emit(line string) {
+	g.lines = append(g.lines, line)
+}
+
+// temp - new temporary variable, its name can't be the name of a variable
+func (g *generator) temp() string {
+	g.temps++
+	return "_t" + strconv.Itoa(g.temps)
+}
+
+// block - statements of the function, which are generated separately
+func (g *generator) block(gen func() (code, error)) ([]string, code, error) {
+	saved := g.lines
+	g.lines = nil
+	res, err := gen()
+	lines := g.lines
+	g.lines = saved
+	return lines, res, err
+}
+
+// store - the value in the temporary variable, if it isn't simple
+func (g *generator) store(c code) string {
+	if c.prec == precPrimary && !strings.Contains(c.str, "(") {
+		return c.str
+	}
+	t := g.temp()
+	g.emit(t + " := " + c.str)
+	return t
+}
+
+// number - Go float literal
+func (g *generator) number(val float64) code {
+	switch {
+	case math.IsNaN(val):
+		g.imports["math"] = true
+		return code{"math.NaN()", precPrimary}
+	case math.IsInf(val, 0):
+		g.imports["math"] = true
+		if val > 0 {
+			return code{"math.Inf(1)", precPrimary}
+		}
+		return code{"math.Inf(-1)", precPrimary}
+	}
+	str := strconv.FormatFloat(val, 'g', -1, 64)
+	if !strings.ContainsAny(str, ".e") {
+		str += ".0"
+	}
+	if val < 0 {
+		return code{str, precSum}
+	}
+	return code{str, precPrimary}
+}
+
+// paren - the operand in parenthesis, if it binds weaker than the operator
+func paren(c code, prec int) string {
+	if c.prec < prec {
+		return "(" + c.str + ")"
+	}
+	return c.str
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// constant - value of the subtree without variables and errors
+func constant(exp interfaces.Expression) (float64, bool) {
+	switch e := exp.(type) {
+	case *internal.Term:
+		if e.Val == "" {
+			return 0, true
+		}
+		val, err := strconv.ParseFloat(e.Val, 64)
+		return val, err == nil
+
+	case *internal.Unary:
+		x, ok := constant(e.Exp)
+		switch {
+		case ok && e.Op == "-":
+			return -x, true
+		case ok && e.Op == "+":
+			return x, true
+		}
+
+	case *internal.Node:
+		x, ok := constant(e.LExp)
+		if !ok {
+			return 0, false
+		}
+		y, ok := constant(e.RExp)
+		if !ok {
+			return 0, false
+		}
+		switch e.Op {
+		case "+":
+			return x + y, true
+		case "-":
+			return x - y, true
+		case "*":
+			return x * y, true
+		case "/":
+			return x / y, y != 0
+		case "^":
+			return math.Pow(x, y), true
+		case "<":
+			return boolToFloat(x < y), true
+		case "<=":
+			return boolToFloat(x <= y), true
+		case ">":
+			return boolToFloat(x > y), true
+		case ">=":
+			return boolToFloat(x >= y), true
+		case "==":
+			return boolToFloat(x == y), true
+		case "!=":
+			return boolToFloat(x != y), true
+		}
+	}
+	return 0, false
+}
+
+// comparisons - Go operators of the comparisons
+var comparisons = map[string]bool{"<": true, "<=": true, ">": true, ">=": true, "==": true, "!=": true}
+
+func (g *generator) expr(exp interfaces.Expression) (code, error) {
+	if val, ok := constant(exp); ok {
+		return g.number(val), nil
+	}
+	switch e := exp.(type) {
+	case *internal.Term:
+		if internal.IsQuoted(e.Val) {
+			return code{}, errors.New("string " + e.Val + " can't be evaluated as a number")
+		}
+		name, err := g.name(e.Val)
+		return code{name, precPrimary}, err
+
+	case *internal.Unary:
+		x, err := g.expr(e.Exp)
+		if err != nil {
+			return code{}, err
+		}
+		switch e.Op {
+		case "-":
+			if x.prec < precPrimary || strings.HasPrefix(x.str, "-") {
+				return code{"-(" + x.str + ")", precPrimary}, nil
+			}
+			return code{"-" + x.str, precPrimary}, nil
+		case "+":
+			return x, nil
+		}
+		return g.call(e.Op, []interfaces.Expression{e.Exp})
+
+	case *internal.Node:
+		return g.node(e)
+
+	case interfaces.Function:
+		return g.call(e.GetOperation(), e.GetArgs())
+
+	case *internal.Let:
+		return g.let(e)
+
+	case *internal.Assign:
+		return g.assign(e)
+	}
+	return code{}, errors.New("not supported node '" + exp.String() + "' in Go code")
+}
+
+func (g *generator) node(n *internal.Node) (code, error) {
+	x, err := g.expr(n.LExp)
+	if err != nil {
+		return code{}, err
+	}
+	y, err := g.expr(n.RExp)
+	if err != nil {
+		return code{}, err
+	}
+
+	switch n.Op {
+	case "+", "-":
+		return code{paren(x, precSum) + " " + n.Op + " " + paren(y, precSum+1), precSum}, nil
+	case "*":
+		return code{paren(x, precProduct) + " * " + paren(y, precProduct+1), precProduct}, nil
+	case "^":
+		g.imports["math"] = true
+		return code{"math.Pow(" + x.str + ", " + y.str + ")", precPrimary}, nil
+	case "%":
+		// the integer parts are taken at run time, Go doesn't compile the conversion of the constant int(0.5)
+		g.imports["math"] = true
+		divisor := g.temp()
+		g.emit(divisor + " := int(math.Trunc(" + y.str + "))")
+		if val, ok := constant(n.RExp); !ok || int(val) == 0 {
+			g.imports["errors"] = true
+			g.emit("if " + divisor + " == 0 {")
+			g.emit("return 0, errors.New(\"incorrect divisor for % operator\")")
+			g.emit("}")
+		}
+		return code{"float64(int(math.Trunc(" + x.str + ")) % " + divisor + ")", precPrimary}, nil
+	case "/":
+		divisor := g.store(y)
+		val, ok := constant(n.RExp)
+		if ok && val == 0 {
+			// Go doesn't compile the division by the constant zero
+			divisor = g.temp()
+			g.emit(divisor + " := " + y.str)
+		}
+		if !ok || val == 0 {
+			g.imports["errors"] = true
+			g.emit("if " + divisor + " == 0 {")
+			g.emit("return 0, errors.New(\"incorrect divisor for division operator\")")
+			g.emit("}")
+		}
+		return code{paren(x, precProduct) + " / " + divisor, precProduct}, nil
+	}
+	if comparisons[n.Op] {
+		t := g.temp()
+		g.emit("var " + t + " float64")
+		g.emit("if " + x.str + " " + n.Op + " " + y.str + " {")
+		g.emit(t + " = 1")
+		g.emit("}")
+		return code{t, precPrimary}, nil
+	}
+	return code{}, errors.New("not supported binary operation '" + n.Op + "' in Go code")
+}
+
+// arity - checks the count of args of the built-in function
+func arity(name string, args []interfaces.Expression, count int) error {
+	if len(args) != count {
+		return errors.New("incorrect count of args for '" + name + "' function. Need: " + strconv.Itoa(count) +
+			", but get: " + strconv.Itoa(len(args)))
+	}
+	return nil
+}
+
+func (g *generator) call(name string, args []interfaces.Expression) (code, error) {
+	switch name {
+	case "sqrt", "abs":
+		if err := arity(name, args, 1); err != nil {
+			return code{}, err
+		}
+		x, err := g.expr(args[0])
+		if err != nil {
+			return code{}, err
+		}
+		g.imports["math"] = true
+		if name == "abs" {
+			return code{"math.Abs(" + x.str + ")", precPrimary}, nil
+		}
+		arg := g.store(x)
+		g.imports["errors"], g.imports["fmt"] = true, true
+		g.emit("if " + arg + " < 0 {")
+		g.emit("return 0, errors.New(\"'sqrt' function argument is negative: \" + fmt.Sprintf(\"%f\", " + arg + "))")
+		g.emit("}")
+		return code{"math.Sqrt(" + arg + ")", precPrimary}, nil
+
+	case "if":
+		if err := arity(name, args, 3); err != nil {
+			return code{}, err
+		}
+		return g.piecewise(args, false)
+
+	case "piecewise", "case":
+		if len(args) < 2 {
+			return code{}, errors.New("incorrect count of args for 'piecewise' function. Need: 2 or more, but get: " +
+				strconv.Itoa(len(args)))
+		}
+		return g.piecewise(args, true)
+
+	case "iferror":
+		if err := arity(name, args, 2); err != nil {
+			return code{}, err
+		}
+		return g.ifError(args)
+
+	case "coalesce":
+		if len(args) < 1 {
+			return code{}, errors.New("incorrect count of args for 'coalesce' function. Need: 1 or more, but get: 0")
+		}
+		return g.coalesce(args)
+	}
+	return code{}, errors.New("not supported function '" + name + "' in Go code")
+}
+
+// piecewise - the chain of conditions, the value of the first true condition is stored in the temporary variable
+func (g *generator) piecewise(args []interfaces.Expression, piecewise bool) (code, error) {
+	t := g.temp()
+	g.emit("var " + t + " float64")
+	var branch func(i int) error
+	branch = func(i int) error {
+		if i == len(args)-1 {
+			lines, val, err := g.block(func() (code, error) { return g.expr(args[i]) })
+			if err != nil {
+				return err
+			}
+			g.lines = append(g.lines, lines...)
+			g.emit(t + " = " + val.str)
+			return nil
+		}
+		if i == len(args) {
+			g.imports["errors"] = true
+			g.emit("return 0, errors.New(\"no condition of 'piecewise' function is satisfied\")")
+			return nil
+		}
+		cond, err := g.condition(args[i])
+		if err != nil {
+			return err
+		}
+		lines, val, err := g.block(func() (code, error) { return g.expr(args[i+1]) })
+		if err != nil {
+			return err
+		}
+		g.emit("if " + cond + " {")
+		g.lines = append(g.lines, lines...)
+		g.emit(t + " = " + val.str)
+		g.emit("} else {")
+		if err := branch(i + 2); err != nil {
+			return err
+		}
+		g.emit("}")
+		return nil
+	}
+	return code{t, precPrimary}, branch(0)
+}
+
+// condition - Go condition of the expression, non-zero value is true
+func (g *generator) condition(exp interfaces.Expression) (string, error) {
+	if n, ok := exp.(*internal.Node); ok && comparisons[n.Op] {
+		if _, ok := constant(n); !ok {
+			x, err := g.expr(n.LExp)
+			if err != nil {
+				return "", err
+			}
+			y, err := g.expr(n.RExp)
+			if err != nil {
+				return "", err
+			}
+			return x.str + " " + n.Op + " " + y.str, nil
+		}
+	}
+	cond, err := g.expr(exp)
+	if err != nil {
+		return "", err
+	}
+	return paren(cond, precPrimary) + " != 0", nil
+}
+
+// closure - the function literal with the statements, which returns the value or the error
+func closure(lines []string, val code) string {
+	lines = append(lines, "return "+val.str+", nil")
+	return "func() (float64, error) {\n" + strings.Join(lines, "\n") + "\n}()"
+}
+
+// ifError - the fallback is evaluated only if the expression fails
+func (g *generator) ifError(args []interfaces.Expression) (code, error) {
+	lines, val, err := g.block(func() (code, error) { return g.expr(args[0]) })
+	if err != nil {
+		return code{}, err
+	}
+	// the expression without statements can't fail
+	if len(lines) == 0 {
+		return val, nil
+	}
+	fn := closure(lines, val)
+	lines, fallback, err := g.block(func() (code, error) { return g.expr(args[1]) })
+	if err != nil {
+		return code{}, err
+	}
+	t := g.temp()
+	g.emit(t + ", err := " + fn)
+	g.emit("if err != nil {")
+	g.lines = append(g.lines, lines...)
+	g.emit(t + " = " + fallback.str)
+	g.emit("}")
+	return code{t, precPrimary}, nil
+}
+
+// coalesce - the first argument, which is evaluated without error and isn't NaN
+func (g *generator) coalesce(args []interfaces.Expression) (code, error) {
+	body := []string{"var err error"}
+	for _, arg := range args {
+		lines, val, err := g.block(func() (code, error) { return g.expr(arg) })
+		if err != nil {
+			return code{}, err
+		}
+		v := val.str
+		if len(lines) == 0 {
+			body = append(body, "err = nil")
+		} else {
+			v = g.temp()
+			body = append(body, v+", err := "+closure(lines, val))
+		}
+		body = append(body, "if err == nil && !math.IsNaN("+v+") {", "return "+v+", nil", "}")
+	}
+	g.imports["math"], g.imports["errors"] = true, true
+	body = append(body, "if err == nil {", "err = errors.New(\"all args of 'coalesce' function are NaN\")", "}",
+		"return 0, err")
+	t := g.temp()
+	g.emit(t + ", err := func() (float64, error) {\n" + strings.Join(body, "\n") + "\n}()")
+	g.emit("if err != nil {")
+	g.emit("return 0, err")
+	g.emit("}")
+	return code{t, precPrimary}, nil
+}
+
+// let - the bound name is the variable of the block
+func (g *generator) let(l *internal.Let) (code, error) {
+	value, err := g.expr(l.Value)
+	if err != nil {
+		return code{}, err
+	}
+	name, err := g.name(l.Name)
+	if err != nil {
+		return code{}, err
+	}
+	lines, body, err := g.block(func() (code, error) { return g.expr(l.Body) })
+	if err != nil {
+		return code{}, err
+	}
+	t := g.temp()
+	g.emit("var " + t + " float64")
+	g.emit("{")
+	g.emit(name + " := " + value.str)
+	if !uses(l.Body, l.Name) {
+		g.emit("_ = " + name)
+	}
+	g.lines = append(g.lines, lines...)
+	g.emit(t + " = " + body.str)
+	g.emit("}")
+	return code{t, precPrimary}, nil
+}
+
+// uses - checks that the variable is used by the expression
+func uses(exp interfaces.Expression, name string) bool {
+	vars := make(map[string]interface{})
+	exp.GetVarList(vars)
+	_, ok := vars[name]
+	return ok
+}
+
+// assign - the variable is declared at the first assignment, the value is the variable
+func (g *generator) assign(a *internal.Assign) (code, error) {
+	value, err := g.expr(a.Exp)
+	if err != nil {
+		return code{}, err
+	}
+	name, err := g.name(a.Name)
+	if err != nil {
+		return code{}, err
+	}
+	if g.params[a.Name] || g.declared[a.Name] {
+		g.emit(name + " = " + value.str)
+	} else {
+		g.emit(name + " := " + value.str)
+		g.declared[a.Name] = true
+	}
+	return code{name, precPrimary}, nil
+}
+
+// script - statements of the function, the value of the last one is the result
+func (g *generator) script(s *internal.Script) (code, error) {
+	var res code
+	for i, stmt := range s.Stmts {
+		val, err := g.expr(stmt)
+		if err != nil {
+			return code{}, err
+		}
+		res = val
+		if i == len(s.Stmts)-1 {
+			break
+		}
+		// the values of statements and the variables, which aren't used later, are discarded
+		a, ok := stmt.(*internal.Assign)
+		if !ok {
+			g.emit("_ = " + val.str)
+			continue
+		}
+		used := false
+		for _, next := range s.Stmts[i+1:] {
+			used = used || uses(next, a.Name)
+		}
+		if !used && !g.params[a.Name] {
+			g.emit("_ = " + val.str)
+		}
+	}
+	return res, nil
+}
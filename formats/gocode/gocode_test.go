@@ -0,0 +1,160 @@
+package gocode_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/formats/gocode"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+func TestGenerate(t *testing.T) {
+	p := parser.NewParser()
+	exp, _ := p.Parse("price * qty * (1 - 15 / 100) + sqrt(fee) / n")
+	res, err := p.GenerateGo(exp, "pricing", "Total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	need := `// Code generated by go-math-expression-parser; DO NOT EDIT.
+
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Total - price * qty * (1 - 15 / 100) + sqrt(fee) / n
+func Total(fee, n, price, qty float64) (float64, error) {
+	if fee < 0 {
+		return 0, errors.New("'sqrt' function argument is negative: " + fmt.Sprintf("%f", fee))
+	}
+	if n == 0 {
+		return 0, errors.New("incorrect divisor for division operator")
+	}
+	return price*qty*0.85 + math.Sqrt(fee)/n, nil
+}
+`
+	if string(res) != need {
+		t.Error("incorrect code:\n" + string(res))
+	}
+
+	p.AddFunction(func(args ...float64) (float64, error) { return 1, nil }, "foo")
+	type TestData struct {
+		input  string
+		params []string
+		name   string
+	}
+	data := []TestData{
+		{"foo(x)", []string{"x"}, "F"},
+		{"to(x, \"km\")", []string{"x"}, "F"},
+		{"sqrt(x, y)", []string{"x", "y"}, "F"},
+		{"x", []string{"x"}, "func"},
+		{"a.b + a_b", []string{"a.b", "a_b"}, "F"},
+	}
+	for _, d := range data {
+		exp, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if _, err := gocode.Generate(exp, gocode.Options{Package: "p", Func: d.name, Params: d.params}); err == nil {
+			t.Error("incorrect error handling of '" + d.input + "'")
+		}
+	}
+}
+
+// TestGeneratedCode - the generated functions return the same results as the evaluator
+func TestGeneratedCode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the generated code isn't compiled in short mode")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool isn't found")
+	}
+
+	corpus := []string{
+		"price * qty * (1 - 15 / 100) + 2 ^ 3", "a - (b - c) * -a", "a / b % 3", "-(a + b) ^ 2",
+		"tax = a * 0.2; total = a + tax; total * b / c", "a = a + 1; unused = 5; a * 2",
+		"let m = a - b in if(m > 0, sqrt(m), iferror(1 / m, coalesce(c, b % 2)))",
+		"piecewise(a < 0, -a, a == 0, 0)", "if(a >= b, a != c, a <= c) + (a > b)", "iferror(sqrt(a - 10), -1)",
+		"coalesce(sqrt(a), 0 / 0, c)", "let func = a in func * abs(b)", "let x = 1 in 2", "1e21 + a",
+		"a % 0.5", "a % 2.5 + 7.5 % b",
+	}
+	vars := []map[string]float64{
+		{"price": 10, "qty": 3, "a": 4, "b": 2, "c": 5},
+		{"price": 0, "qty": 0, "a": -1, "b": 0, "c": 0},
+		{"price": 1, "qty": 1, "a": 0, "b": -3, "c": 0.5},
+	}
+
+	p := parser.NewParser()
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module generated\n\ngo 1.19\n")
+
+	var main strings.Builder
+	var need []string
+	main.WriteString("package main\n\nimport \"fmt\"\n\nfunc main() {\n")
+	for i, input := range corpus {
+		exp, err := p.Parse(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := "F" + strconv.Itoa(i)
+		src, err := p.GenerateGo(exp, "main", name)
+		if err != nil {
+			t.Fatal("can't generate '" + input + "': " + err.Error())
+		}
+		write(strings.ToLower(name)+".go", string(src))
+
+		params := parser.GetVarList(exp)
+		for _, v := range vars {
+			args := make([]string, len(params))
+			for j, param := range params {
+				args[j] = strconv.FormatFloat(v[param], 'g', -1, 64)
+			}
+			main.WriteString("\tfmt.Println(result(" + name + "(" + strings.Join(args, ", ") + ")))\n")
+			res, err := exp.Evaluate(v, p)
+			if err != nil {
+				need = append(need, "error: "+err.Error())
+			} else {
+				need = append(need, strconv.FormatFloat(res, 'g', -1, 64))
+			}
+		}
+	}
+	main.WriteString("}\n\nfunc result(val float64, err error) string {\n\tif err != nil {\n\t\treturn \"error: \" + err.Error()\n\t}\n" +
+		"\treturn fmt.Sprint(val)\n}\n")
+	write("main.go", main.String())
+
+	cmd := exec.Command(goTool, "vet", ".")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Error("generated code isn't vetted: " + err.Error() + "\n" + string(out))
+	}
+	cmd = exec.Command(goTool, "run", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatal("generated code fails: " + err.Error() + "\n" + string(out))
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	for i, line := range lines {
+		if i < len(need) && line != need[i] {
+			t.Error("incorrect result of '" + corpus[i/len(vars)] + "' with vars " + strconv.Itoa(i%len(vars)) +
+				": " + line + ", need: " + need[i])
+		}
+	}
+	if len(lines) != len(need) {
+		t.Error("incorrect count of results: " + strconv.Itoa(len(lines)))
+	}
+}
@@ -0,0 +1,86 @@
+package infix
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Builder - collects operands and operators of the infix expression for the parser.
+// Adjacent operands are multiplied: 2 x (y + 1) is written as 2*x*(y + 1)
+type Builder struct {
+	parts   []string
+	operand bool
+}
+
+// Operand - number, name, function call or expression in parenthesis
+func (b *Builder) Operand(str string) {
+	if b.operand {
+		b.parts = append(b.parts, "*")
+	}
+	b.parts = append(b.parts, str)
+	b.operand = true
+}
+
+// Operator - binary or unary operator
+func (b *Builder) Operator(op string) {
+	b.parts = append(b.parts, op)
+	b.operand = false
+}
+
+// Power - raise the last operand to the power, it binds tighter than any operator: 2x^2 = 2*(x^2)
+func (b *Builder) Power(exp string) error {
+	if !b.operand {
+		return errors.New("power without base")
+	}
+	last := len(b.parts) - 1
+	b.parts[last] = "((" + b.parts[last] + ")^(" + exp + "))"
+	return nil
+}
+
+// Subscript - add the index to the last operand, which must be a name: x_1
+func (b *Builder) Subscript(index string) error {
+	if !b.operand {
+		return errors.New("subscript without base")
+	}
+	b.parts[len(b.parts)-1] += "_" + index
+	return nil
+}
+
+// Empty - checks that nothing is added
+func (b *Builder) Empty() bool {
+	return len(b.parts) == 0
+}
+
+// String - the infix expression
+func (b *Builder) String() string {
+	str := ""
+	for i, part := range b.parts {
+		if i > 0 {
+			str += " "
+		}
+		str += part
+	}
+	return str
+}
+
+// Group - the expression in parenthesis
+func Group(str string) string {
+	return "(" + str + ")"
+}
+
+// IsIdentifier - checks that the text is the name of the variable or the function: letters, digits, '_' and '.',
+// the first rune is a letter or '_'. Other text would be parsed as the part of the expression
+func IsIdentifier(name string) bool {
+	for i, c := range name {
+		if !unicode.IsLetter(c) && c != '_' && (i == 0 || !unicode.IsDigit(c) && c != '.') {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// IsText - checks that the text can be written as the string literal
+func IsText(text string) bool {
+	return !strings.ContainsAny(text, "\"\n")
+}
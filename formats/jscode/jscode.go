@@ -0,0 +1,273 @@
+package jscode
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// Options - names of the variables and the user functions
+type Options struct {
+	// Variable - JavaScript of the variable: row["price"], the name is used as is if it's nil
+	Variable func(name string) string
+	// Function - call of the user function, ok is false if the function isn't supported
+	Function func(name string, args []string) (js string, ok bool)
+}
+
+// JavaScript precedence of the operators, primary expressions are literals, variables, calls and parenthesis
+const (
+	precCond    = 1
+	precSum     = 2
+	precProduct = 3
+	precUnary   = 4
+	precPrimary = 5
+)
+
+// code - JavaScript expression and its precedence
+type code struct {
+	str  string
+	prec int
+}
+
+// JavaScript operators of the comparisons
+var comparisons = map[string]string{"<": "<", "<=": "<=", ">": ">", ">=": ">=", "==": "===", "!=": "!=="}
+
+type writer struct {
+	opts Options
+	// values of the let-bindings, they are substituted to the body
+	scope map[string]code
+}
+
+// Write - JavaScript expression of the tree: price * qty - Math.pow(x, 2).
+// Comparisons are (a < b ? 1 : 0), if and piecewise are conditional operators, piecewise without the default
+// value is NaN if no condition is satisfied, coalesce skips NaN values, let-bindings are substituted.
+// Errors of the evaluator aren't thrown: the division by zero is Infinity, the root of negative number is NaN.
+// Strings, iferror, assignments and scripts aren't supported
+func Write(exp interfaces.Expression, opts Options) (string, error) {
+	if opts.Variable == nil {
+		opts.Variable = func(name string) string { return name }
+	}
+	w := writer{opts: opts, scope: make(map[string]code)}
+	res, err := w.expr(exp)
+	if err != nil {
+		return "", err
+	}
+	return res.str, nil
+}
+
+// paren - the operand in parenthesis, if it binds weaker than the operator
+func paren(c code, prec int) string {
+	if c.prec < prec {
+		return "(" + c.str + ")"
+	}
+	return c.str
+}
+
+func number(val float64) code {
+	switch {
+	case math.IsNaN(val):
+		return code{"NaN", precPrimary}
+	case math.IsInf(val, 1):
+		return code{"Infinity", precPrimary}
+	case math.IsInf(val, -1):
+		return code{"-Infinity", precUnary}
+	case val < 0:
+		return code{strconv.FormatFloat(val, 'g', -1, 64), precUnary}
+	}
+	return code{strconv.FormatFloat(val, 'g', -1, 64), precPrimary}
+}
+
+func (w *writer) expr(exp interfaces.Expression) (code, error) {
+	switch e := exp.(type) {
+	case *internal.Term:
+		if e.Val == "" {
+			return code{"0", precPrimary}, nil
+		}
+		if val, err := strconv.ParseFloat(e.Val, 64); err == nil {
+			return number(val), nil
+		}
+		if internal.IsQuoted(e.Val) {
+			return code{}, errors.New("not supported string " + e.Val + " in JavaScript")
+		}
+		if c, ok := w.scope[e.Val]; ok {
+			return c, nil
+		}
+		return code{w.opts.Variable(e.Val), precPrimary}, nil
+
+	case *internal.Unary:
+		switch e.Op {
+		case "-":
+			x, err := w.expr(e.Exp)
+			if err != nil {
+				return code{}, err
+			}
+			// "--" is the decrement operator
+			if x.prec < precUnary || strings.HasPrefix(x.str, "-") {
+				return code{"-(" + x.str + ")", precUnary}, nil
+			}
+			return code{"-" + x.str, precUnary}, nil
+		case "+":
+			return w.expr(e.Exp)
+		}
+		return w.call(e.Op, []interfaces.Expression{e.Exp})
+
+	case *internal.Node:
+		return w.node(e)
+
+	case interfaces.Function:
+		return w.call(e.GetOperation(), e.GetArgs())
+
+	case *internal.Let:
+		value, err := w.expr(e.Value)
+		if err != nil {
+			return code{}, err
+		}
+		if value.prec < precPrimary {
+			value = code{"(" + value.str + ")", precPrimary}
+		}
+		saved, shadowed := w.scope[e.Name]
+		w.scope[e.Name] = value
+		res, err := w.expr(e.Body)
+		if shadowed {
+			w.scope[e.Name] = saved
+		} else {
+			delete(w.scope, e.Name)
+		}
+		return res, err
+
+	case *internal.Assign:
+		return code{}, errors.New("not supported assignment to '" + e.Name + "' in JavaScript")
+
+	case *internal.Script:
+		return code{}, errors.New("not supported script in JavaScript")
+	}
+	return code{}, errors.New("not supported node '" + exp.String() + "' in JavaScript")
+}
+
+func (w *writer) node(n *internal.Node) (code, error) {
+	if _, ok := comparisons[n.Op]; ok {
+		cond, err := w.condition(n)
+		if err != nil {
+			return code{}, err
+		}
+		return code{cond + " ? 1 : 0", precCond}, nil
+	}
+
+	x, err := w.expr(n.LExp)
+	if err != nil {
+		return code{}, err
+	}
+	y, err := w.expr(n.RExp)
+	if err != nil {
+		return code{}, err
+	}
+	switch n.Op {
+	case "+", "-":
+		return code{paren(x, precSum) + " " + n.Op + " " + paren(y, precSum+1), precSum}, nil
+	case "*", "/":
+		return code{paren(x, precProduct) + " " + n.Op + " " + paren(y, precProduct+1), precProduct}, nil
+	case "^":
+		return code{"Math.pow(" + x.str + ", " + y.str + ")", precPrimary}, nil
+	case "%":
+		// the evaluator takes the remainder of the integer parts
+		return code{"Math.trunc(" + x.str + ") % Math.trunc(" + y.str + ")", precProduct}, nil
+	}
+	return code{}, errors.New("not supported binary operation '" + n.Op + "' in JavaScript")
+}
+
+// condition - JavaScript condition of the expression, non-zero value is true
+func (w *writer) condition(exp interfaces.Expression) (string, error) {
+	if n, ok := exp.(*internal.Node); ok {
+		if op, ok := comparisons[n.Op]; ok {
+			x, err := w.expr(n.LExp)
+			if err != nil {
+				return "", err
+			}
+			y, err := w.expr(n.RExp)
+			if err != nil {
+				return "", err
+			}
+			return paren(x, precSum) + " " + op + " " + paren(y, precSum), nil
+		}
+	}
+	x, err := w.expr(exp)
+	if err != nil {
+		return "", err
+	}
+	return paren(x, precSum) + " !== 0", nil
+}
+
+func (w *writer) call(name string, args []interfaces.Expression) (code, error) {
+	switch name {
+	case "if", "piecewise", "case":
+		if name == "if" && len(args) != 3 || len(args) < 2 {
+			return code{}, errors.New("incorrect count of args for '" + name + "' function: " + strconv.Itoa(len(args)))
+		}
+		res := code{"NaN", precPrimary}
+		if len(args)%2 == 1 {
+			val, err := w.expr(args[len(args)-1])
+			if err != nil {
+				return code{}, err
+			}
+			res = val
+		}
+		// the chain is built from the last condition: c1 ? v1 : c2 ? v2 : default
+		for i := len(args)/2*2 - 2; i >= 0; i -= 2 {
+			cond, err := w.condition(args[i])
+			if err != nil {
+				return code{}, err
+			}
+			val, err := w.expr(args[i+1])
+			if err != nil {
+				return code{}, err
+			}
+			res = code{cond + " ? " + paren(val, precSum) + " : " + res.str, precCond}
+		}
+		return res, nil
+
+	case "coalesce":
+		if len(args) < 1 {
+			return code{}, errors.New("incorrect count of args for 'coalesce' function: 0")
+		}
+		strs, err := w.args(args)
+		if err != nil {
+			return code{}, err
+		}
+		return code{"([" + strings.Join(strs, ", ") + "].find((v) => !Number.isNaN(v)) ?? NaN)", precPrimary}, nil
+	}
+
+	strs, err := w.args(args)
+	if err != nil {
+		return code{}, err
+	}
+	switch name {
+	case "sqrt", "abs":
+		if len(strs) == 1 {
+			return code{"Math." + name + "(" + strs[0] + ")", precPrimary}, nil
+		}
+	default:
+		if w.opts.Function != nil {
+			if js, ok := w.opts.Function(name, strs); ok {
+				return code{js, precPrimary}, nil
+			}
+		}
+	}
+	return code{}, errors.New("not supported function '" + name + "' with " + strconv.Itoa(len(args)) +
+		" args in JavaScript")
+}
+
+func (w *writer) args(args []interfaces.Expression) ([]string, error) {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		c, err := w.expr(arg)
+		if err != nil {
+			return nil, err
+		}
+		strs[i] = c.str
+	}
+	return strs, nil
+}
@@ -0,0 +1,120 @@
+package jscode_test
+
+import (
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/formats/jscode"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+func TestWrite(t *testing.T) {
+	p := parser.NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) { return 1, nil }, "foo")
+	type TestData struct {
+		input  string
+		output string
+	}
+	data := []TestData{
+		{"price * qty - x^2", "price * qty - Math.pow(x, 2)"},
+		{"a - (b - c) / (d * e)", "a - (b - c) / (d * e)"},
+		{"-(a + b) * -c", "-((a + b) * -c)"},
+		{"- -a", "-(-a)"},
+		{"c * (a % b)", "c * (Math.trunc(a) % Math.trunc(b))"},
+		{"(a < b) + 1", "(a < b ? 1 : 0) + 1"},
+		{"if(x != 0, sqrt(x), -1) * 2", "(x !== 0 ? Math.sqrt(x) : -1) * 2"},
+		{"piecewise(x < 0, -1, x == 0, 0, 1)", "x < 0 ? -1 : x === 0 ? 0 : 1"},
+		{"piecewise(x, 1, y, abs(y))", "x !== 0 ? 1 : y !== 0 ? Math.abs(y) : NaN"},
+		{"coalesce(a, b, 0)", "([a, b, 0].find((v) => !Number.isNaN(v)) ?? NaN)"},
+		{"let m = a - b in m * m", "(a - b) * (a - b)"},
+		{"1.5e-3 * x", "0.0015 * x"},
+	}
+	for _, d := range data {
+		exp, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if res, err := jscode.Write(exp, jscode.Options{}); err != nil || res != d.output {
+			t.Error("incorrect JavaScript of '" + d.input + "': " + res + ", need: " + d.output)
+		}
+	}
+
+	exp, _ := p.Parse("foo(price, 2) + qty")
+	opts := jscode.Options{
+		Variable: func(name string) string { return "row[" + strconv.Quote(name) + "]" },
+		Function: func(name string, args []string) (string, bool) {
+			return "fns." + name + "(" + strings.Join(args, ", ") + ")", name == "foo"
+		},
+	}
+	if res, err := jscode.Write(exp, opts); err != nil || res != `fns.foo(row["price"], 2) + row["qty"]` {
+		t.Error("incorrect JavaScript with mapping: " + res)
+	}
+
+	for _, input := range []string{"foo(x)", "to(x, \"km\")", "iferror(1 / x, 0)", "a = 1; a", "abs(x, y)"} {
+		exp, err := p.Parse(input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if _, err := jscode.Write(exp, jscode.Options{}); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+}
+
+// TestNode - the JavaScript returns the same results as the evaluator
+func TestNode(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node isn't found")
+	}
+	corpus := []string{
+		"price * qty * (1 - 15 / 100) + 2 ^ 3", "a - (b - c) * -a", "a / b % 3", "-(a + b) ^ 2", "- -a",
+		"let m = a - b in if(m > 0, sqrt(m), coalesce(0 / 0, c, b))", "piecewise(a < 0, -a, a == 0, 0, 1)",
+		"if(a >= b, a != c, a <= c) + (a > b)", "abs(b - a) / 4", "-7.5 % 2 + 1e21",
+	}
+	vars := map[string]float64{"price": 10, "qty": 3, "a": 4, "b": 2, "c": 5}
+
+	p := parser.NewParser()
+	var script strings.Builder
+	for name, val := range vars {
+		script.WriteString("const " + name + " = " + strconv.FormatFloat(val, 'g', -1, 64) + ";\n")
+	}
+	var need []float64
+	for _, input := range corpus {
+		exp, err := p.Parse(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := exp.Evaluate(vars, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		need = append(need, res)
+		js, err := jscode.Write(exp, jscode.Options{})
+		if err != nil {
+			t.Fatal("can't write '" + input + "': " + err.Error())
+		}
+		script.WriteString("console.log(" + js + ");\n")
+	}
+
+	out, err := exec.Command(node, "-e", script.String()).CombinedOutput()
+	if err != nil {
+		t.Fatal("JavaScript fails: " + err.Error() + "\n" + string(out))
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != len(need) {
+		t.Fatal("incorrect count of results: " + strconv.Itoa(len(lines)))
+	}
+	for i, line := range lines {
+		res, err := strconv.ParseFloat(line, 64)
+		if err != nil || math.Abs(res-need[i]) > 1e-9*math.Max(1, math.Abs(need[i])) {
+			t.Error("incorrect result of '" + corpus[i] + "': " + line + ", need: " +
+				strconv.FormatFloat(need[i], 'g', -1, 64))
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package latex
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/overseven/go-math-expression-parser/funcs"
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// Greek - names of the Greek letters, which are written as LaTeX symbols
+var Greek = []string{
+	"alpha", "beta", "gamma", "delta", "epsilon", "varepsilon", "zeta", "eta", "theta", "vartheta", "iota", "kappa",
+	"lambda", "mu", "nu", "xi", "pi", "varpi", "rho", "varrho", "sigma", "varsigma", "tau", "upsilon", "phi", "varphi",
+	"chi", "psi", "omega", "Gamma", "Delta", "Theta", "Lambda", "Xi", "Pi", "Sigma", "Upsilon", "Phi", "Psi", "Omega",
+}
+
+// greekRunes - Unicode Greek letters and their names
+var greekRunes = map[rune]string{
+	'α': "alpha", 'β': "beta", 'γ': "gamma", 'δ': "delta", 'ε': "epsilon", 'ζ': "zeta", 'η': "eta", 'θ': "theta",
+	'ι': "iota", 'κ': "kappa", 'λ': "lambda", 'μ': "mu", 'ν': "nu", 'ξ': "xi", 'π': "pi", 'ρ': "rho", 'σ': "sigma",
+	'ς': "varsigma", 'τ': "tau", 'υ': "upsilon", 'φ': "phi", 'χ': "chi", 'ψ': "psi", 'ω': "omega",
+	'Γ': "Gamma", 'Δ': "Delta", 'Θ': "Theta", 'Λ': "Lambda", 'Ξ': "Xi", 'Π': "Pi", 'Σ': "Sigma", 'Υ': "Upsilon",
+	'Φ': "Phi", 'Ψ': "Psi", 'Ω': "Omega",
+}
+
+// operators - LaTeX symbols of the binary operators, '/' and '^' are written as fractions and superscripts
+var operators = map[string]string{
+	"+": "+", "-": "-", "*": "\\cdot", "%": "\\bmod",
+	"<": "<", "<=": "\\le", ">": ">", ">=": "\\ge", "==": "=", "!=": "\\neq",
+}
+
+// Renderer - converts the expression tree to LaTeX
+type Renderer struct {
+	functions [funcs.LevelsOfOperators]map[string]funcs.FuncType
+	// Templates - LaTeX of the functions, #1, #2, ... are replaced by the arguments: "\\Gamma\\left(#1\\right)"
+	Templates map[string]string
+}
+
+// NewRenderer - create a Renderer for the operators and functions of the parser
+func NewRenderer(functions [funcs.LevelsOfOperators]map[string]funcs.FuncType, templates map[string]string) *Renderer {
+	r := &Renderer{functions: functions, Templates: make(map[string]string, len(templates))}
+	for name, tmpl := range templates {
+		r.Templates[name] = tmpl
+	}
+	return r
+}
+
+// Render - LaTeX of the expression
+func (r *Renderer) Render(exp interfaces.Expression) string {
+	switch e := exp.(type) {
+	case *internal.Term:
+		return Term(e.Val)
+
+	case *internal.Node:
+		return r.node(e)
+
+	case *internal.Unary:
+		if _, ok := operators[e.Op]; !ok {
+			return r.call(e.Op, []interfaces.Expression{e.Exp})
+		}
+		return e.Op + r.operand(e.Exp, 2, 2)
+
+	case interfaces.Function:
+		return r.call(e.GetOperation(), e.GetArgs())
+
+	case *internal.Let:
+		return "\\operatorname{let} " + Term(e.Name) + " = " + r.Render(e.Value) + " \\operatorname{in} " + r.Render(e.Body)
+
+	case *internal.Assign:
+		return Term(e.Name) + " = " + r.Render(e.Exp)
+
+	case *internal.Script:
+		stmts := make([]string, len(e.Stmts))
+		for i, stmt := range e.Stmts {
+			stmts[i] = r.Render(stmt)
+		}
+		return strings.Join(stmts, ", \\quad ")
+	}
+	return exp.String()
+}
+
+// Term - LaTeX of the number, the variable or the quoted string.
+// Greek names are symbols, digits at the end of the name are the index: alpha1 = \alpha_{1}
+func Term(val string) string {
+	if _, err := strconv.ParseFloat(val, 64); err == nil {
+		return val
+	}
+	if internal.IsQuoted(val) {
+		return "\\text{" + escape(val[1:len(val)-1]) + "}"
+	}
+
+	name, index := val, ""
+	if i := strings.LastIndex(val, "_"); i > 0 && i+1 < len(val) {
+		name, index = val[:i], val[i+1:]
+	} else {
+		i := len(val)
+		for i > 0 && val[i-1] >= '0' && val[i-1] <= '9' {
+			i--
+		}
+		if i > 0 {
+			name, index = val[:i], val[i:]
+		}
+	}
+
+	res := symbol(name)
+	if index != "" {
+		res += "_{" + symbol(index) + "}"
+	}
+	return res
+}
+
+// symbol - single letter is written as is, Greek letters as symbols, other names in upright font
+func symbol(name string) string {
+	if str := []rune(name); len(str) == 1 {
+		if greek, ok := greekRunes[str[0]]; ok {
+			return "\\" + greek
+		}
+		return name
+	}
+	for _, greek := range Greek {
+		if greek == name {
+			return "\\" + greek
+		}
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return name
+	}
+	return "\\mathrm{" + escape(name) + "}"
+}
+
+// escape - special characters of LaTeX
+func escape(str string) string {
+	var sb strings.Builder
+	for _, c := range str {
+		switch c {
+		case '\\':
+			sb.WriteString("\\backslash ")
+		case '{', '}', '_', '%', '$', '#', '&':
+			sb.WriteRune('\\')
+			sb.WriteRune(c)
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
+
+// level - priority level of the binary operator, fractions and powers are atoms
+func (r *Renderer) level(exp interfaces.Expression) int {
+	switch e := exp.(type) {
+	case *internal.Node:
+		if e.Op == "/" || e.Op == "^" {
+			return 0
+		}
+		for i := 1; i < len(r.functions); i++ {
+			if _, ok := r.functions[i][e.Op]; ok {
+				return i
+			}
+		}
+		return 1
+	case *internal.Unary:
+		if _, ok := operators[e.Op]; ok {
+			return 1
+		}
+	case *internal.Let, *internal.Assign, *internal.Script:
+		return funcs.LevelsOfOperators
+	}
+	return 0
+}
+
+// operand - LaTeX of the operand in parenthesis, if its level is higher than the limit
+func (r *Renderer) operand(exp interfaces.Expression, limit int, unaryLimit int) string {
+	level := r.level(exp)
+	if _, ok := exp.(*internal.Unary); ok {
+		level = unaryLimit
+	}
+	if level >= limit {
+		return "\\left(" + r.Render(exp) + "\\right)"
+	}
+	return r.Render(exp)
+}
+
+func (r *Renderer) node(n *internal.Node) string {
+	switch n.Op {
+	case "/":
+		return "\\frac{" + r.Render(n.LExp) + "}{" + r.Render(n.RExp) + "}"
+	case "^":
+		base := r.Render(n.LExp)
+		switch b := n.LExp.(type) {
+		case *internal.Term:
+			if strings.HasPrefix(b.Val, "-") {
+				base = "\\left(" + base + "\\right)"
+			}
+		case interfaces.Function:
+		default:
+			base = "\\left(" + base + "\\right)"
+		}
+		return base + "^{" + r.Render(n.RExp) + "}"
+	}
+
+	op, ok := operators[n.Op]
+	if !ok {
+		op = "\\mathbin{" + escape(n.Op) + "}"
+	}
+	level := r.level(n)
+	// the left operand of the same level doesn't need parenthesis, an unary operator is written as is
+	left := r.operand(n.LExp, level+1, 0)
+	right := r.operand(n.RExp, level, 0)
+	if _, ok := n.RExp.(*internal.Unary); ok {
+		right = "\\left(" + r.Render(n.RExp) + "\\right)"
+	}
+	return left + " " + op + " " + right
+}
+
+// call - function by the template, known functions and conditionals, other functions as operators
+func (r *Renderer) call(name string, args []interfaces.Expression) string {
+	rendered := make([]string, len(args))
+	for i, arg := range args {
+		rendered[i] = r.Render(arg)
+	}
+	if tmpl, ok := r.Templates[name]; ok {
+		// replace the longer placeholders first: #12 before #1
+		for i := len(rendered); i > 0; i-- {
+			tmpl = strings.ReplaceAll(tmpl, "#"+strconv.Itoa(i), rendered[i-1])
+		}
+		return tmpl
+	}
+
+	switch {
+	case name == "sqrt" && len(args) == 1:
+		return "\\sqrt{" + rendered[0] + "}"
+	case name == "abs" && len(args) == 1:
+		return "\\left|" + rendered[0] + "\\right|"
+	case name == "if" && len(args) == 3:
+		return cases(rendered)
+	case (name == "piecewise" || name == "case") && len(args) >= 2:
+		return cases(rendered)
+	}
+
+	fname := "\\operatorname{" + escape(name) + "}"
+	if greek := symbol(name); strings.HasPrefix(greek, "\\") && !strings.HasPrefix(greek, "\\mathrm") {
+		fname = greek
+	} else if len([]rune(name)) == 1 && unicode.IsLetter([]rune(name)[0]) {
+		fname = name
+	}
+	return fname + "\\left(" + strings.Join(rendered, ", ") + "\\right)"
+}
+
+// cases - condition/value pairs and the optional default value
+func cases(args []string) string {
+	str := "\\begin{cases} "
+	for i := 0; i+1 < len(args); i += 2 {
+		str += args[i+1] + " & \\text{if } " + args[i] + " \\\\ "
+	}
+	if len(args)%2 == 1 {
+		str += args[len(args)-1] + " & \\text{otherwise} "
+	}
+	return str + "\\end{cases}"
+}
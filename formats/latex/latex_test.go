@@ -0,0 +1,164 @@
+package latex_test
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/formats/latex"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+func TestRender(t *testing.T) {
+	type TestData struct {
+		input  string
+		output string
+	}
+	data := []TestData{
+		{"(a + b) / 2", "\\frac{a + b}{2}"},
+		{"x^2 + y^(n - 1)", "x^{2} + y^{n - 1}"},
+		{"(a + b)^2", "\\left(a + b\\right)^{2}"},
+		{"(x^2)^3", "\\left(x^{2}\\right)^{3}"},
+		{"sqrt(x^2 + 1)", "\\sqrt{x^{2} + 1}"},
+		{"abs(a - b)", "\\left|a - b\\right|"},
+		{"2 * alpha + beta_1 * Omega", "2 \\cdot \\alpha + \\beta_{1} \\cdot \\Omega"},
+		{"θ * x2", "\\theta \\cdot x_{2}"},
+		{"price * qty", "\\mathrm{price} \\cdot \\mathrm{qty}"},
+		{"a - (b - c)", "a - \\left(b - c\\right)"},
+		{"a - b - c", "a - b - c"},
+		{"a * (b + c)", "a \\cdot \\left(b + c\\right)"},
+		{"-(a + b)", "-\\left(a + b\\right)"},
+		{"-a * b", "-a \\cdot b"},
+		{"a * -b", "a \\cdot \\left(-b\\right)"},
+		{"a % 3 <= b", "a \\bmod 3 \\le b"},
+		{"a != b", "a \\neq b"},
+		{"if(x > 0, x, -x)", "\\begin{cases} x & \\text{if } x > 0 \\\\ -x & \\text{otherwise} \\end{cases}"},
+		{"foo(a, 2)", "\\operatorname{foo}\\left(a, 2\\right)"},
+		{"gamma(x + 1)", "\\Gamma\\left(x + 1\\right)"},
+		{"to(v, \"km/h\")", "\\operatorname{to}\\left(v, \\text{km/h}\\right)"},
+		{"y = 2 * x; y^2", "y = 2 \\cdot x, \\quad y^{2}"},
+	}
+
+	p := parser.NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) { return 0, nil }, "foo")
+	p.AddFunction(func(args ...float64) (float64, error) { return 0, nil }, "gamma")
+	p.AddLatexTemplate("gamma", "\\Gamma\\left(#1\\right)")
+	for _, d := range data {
+		exp, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if res := p.Latex(exp); res != d.output {
+			t.Error("incorrect LaTeX of '" + d.input + "': " + res + ", need: " + d.output)
+		}
+	}
+}
+
+func TestTerm(t *testing.T) {
+	type TestData struct {
+		input  string
+		output string
+	}
+	data := []TestData{
+		{"1.5e3", "1.5e3"},
+		{"x", "x"},
+		{"pi", "\\pi"},
+		{"x_max", "x_{\\mathrm{max}}"},
+		{"total_cost", "\\mathrm{total}_{\\mathrm{cost}}"},
+		{"a_b_c", "\\mathrm{a\\_b}_{c}"},
+		{"\"50%\"", "\\text{50\\%}"},
+	}
+	for _, d := range data {
+		if res := latex.Term(d.input); res != d.output {
+			t.Error("incorrect LaTeX of '" + d.input + "': " + res + ", need: " + d.output)
+		}
+	}
+}
+
+func TestParseLatex(t *testing.T) {
+	type TestData struct {
+		input  string
+		infix  string
+		output float64
+	}
+	data := []TestData{
+		{"\\frac{a}{b} + \\sqrt{x^{2}}", "a / b + sqrt(x ^ 2)", 4.5},
+		{"2xy", "2 * x * y", 48},
+		{"2x^2 - 3(y - 1)", "2 * (x ^ 2) - 3 * (y - 1)", 17},
+		{"\\left|b - a\\right| \\cdot \\left(a + 1\\right)", "abs(b - a) * (a + 1)", 2},
+		{"\\sqrt[3]{8} + |a - b|", "8 ^ (1 / 3) + abs(a - b)", 3},
+		{"x_{1} \\times x_2 \\div 2", "x_1 * x_2 / 2", 15},
+		{"\\alpha_{max} + \\beta", "alpha_max + beta", 3},
+		{"\\mathrm{price} \\cdot \\mathrm{qty}", "price * qty", 30},
+		{"\\operatorname{foo}\\left(a, y\\right) + \\operatorname{foo}(1, 2)", "foo(a, y) + foo(1, 2)", 10},
+		{"a \\le b", "a <= b", 1},
+		{"2\\pi", "2 * 3.141592653589793", 6.283185307179586},
+		{"\\begin{cases} x & \\text{if } x > y \\\\ -x & \\text{otherwise} \\end{cases}", "piecewise(x > y, x, -x)", -4},
+		{"\\dfrac{1}{2}\\, x", "1 / 2 * x", 2},
+	}
+
+	p := parser.NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) { return args[0] + args[1], nil }, "foo")
+	vars := map[string]float64{"a": 1, "b": 2, "x": 4, "y": 6, "x_1": 5, "x_2": 6, "alpha_max": 1, "beta": 2,
+		"price": 10, "qty": 3}
+	for _, d := range data {
+		exp, err := p.ParseLatex(d.input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		need, err := p.Parse(d.infix)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if exp.String() != need.String() {
+			t.Error("incorrect tree of '" + d.input + "': " + exp.String() + ", need: " + need.String())
+		}
+		res, err := exp.Evaluate(vars, p)
+		if err != nil {
+			t.Error(err)
+		}
+		if math.Abs(res-d.output) > 1e-9 {
+			t.Error("incorrect result of '" + d.input + "': " + strconv.FormatFloat(res, 'g', -1, 64))
+		}
+	}
+
+	for _, input := range []string{"\\frac{a}", "\\unknown{x}", "a +* ", "\\left( a", "\\begin{matrix} a \\end{matrix}", "^2", "{}",
+		"\\mathrm{g(x) = x * 1000}", "x = 3", "\\mathrm{a; b}", "\\text{a\"b}"} {
+		if _, err := p.ParseLatex(input); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+	if _, ok := p.Definitions["g"]; ok {
+		t.Error("function is defined by LaTeX formula")
+	}
+
+	// rendered LaTeX is parsed back to the equivalent expression
+	for _, input := range []string{"(a + b) / 2 - sqrt(x^2 + 1)", "abs(a - b) * -y", "x_1 * x_2 + foo(a, 2)"} {
+		exp, err := p.Parse(input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		parsed, err := p.ParseLatex(p.Latex(exp))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if parsed.String() != exp.String() {
+			t.Error("incorrect tree of rendered '" + input + "': " + parsed.String() + ", need: " + exp.String())
+		}
+	}
+
+	// the locale of the parser doesn't change the numbers and the arguments of LaTeX
+	p.Locale = parser.EuropeanLocale
+	exp, err := p.ParseLatex("\\frac{1.5}{2} + \\operatorname{foo}(1, 2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := exp.Evaluate(nil, p); err != nil || res != 3.75 {
+		t.Error("incorrect result of LaTeX with the locale: " + strconv.FormatFloat(res, 'g', -1, 64))
+	}
+}
@@ -0,0 +1,520 @@
+package latex
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/overseven/go-math-expression-parser/formats/infix"
+)
+
+// reader - converts LaTeX to the infix syntax of the parser
+type reader struct {
+	src        []rune
+	pos        int
+	isFunction func(name string) bool
+}
+
+// ToInfix - convert LaTeX formula to the infix syntax of the parser. Single letters are variables
+// and adjacent operands are multiplied: 2xy = 2*x*y. Names for which isFunction returns true are function calls
+func ToInfix(src string, isFunction func(name string) bool) (string, error) {
+	r := &reader{src: []rune(src), isFunction: isFunction}
+	str, err := r.sequence()
+	if err != nil {
+		return "", err
+	}
+	if r.pos < len(r.src) {
+		return "", r.unexpected()
+	}
+	return str, nil
+}
+
+func (r *reader) unexpected() error {
+	if r.pos >= len(r.src) {
+		return errors.New("unexpected end of LaTeX formula")
+	}
+	return errors.New("unexpected '" + string(r.src[r.pos]) + "' at " + strconv.Itoa(r.pos) + " position")
+}
+
+func (r *reader) skipSpaces() {
+	for r.pos < len(r.src) && unicode.IsSpace(r.src[r.pos]) {
+		r.pos++
+	}
+}
+
+func (r *reader) at(prefix string) bool {
+	p := []rune(prefix)
+	return r.pos+len(p) <= len(r.src) && string(r.src[r.pos:r.pos+len(p)]) == prefix
+}
+
+func (r *reader) expect(prefix string) error {
+	r.skipSpaces()
+	if !r.at(prefix) {
+		return r.unexpected()
+	}
+	r.pos += len([]rune(prefix))
+	return nil
+}
+
+// sequence - operands and operators until the end or one of the stop strings
+func (r *reader) sequence(stops ...string) (string, error) {
+	var b infix.Builder
+	for {
+		r.skipSpaces()
+		if r.pos == len(r.src) {
+			return b.String(), nil
+		}
+		for _, stop := range stops {
+			if r.at(stop) {
+				return b.String(), nil
+			}
+		}
+		if err := r.item(&b); err != nil {
+			return "", err
+		}
+	}
+}
+
+// group - content of the braces
+func (r *reader) group(open, close string) (string, error) {
+	if err := r.expect(open); err != nil {
+		return "", err
+	}
+	str, err := r.sequence(close)
+	if err != nil {
+		return "", err
+	}
+	if str == "" {
+		return "", errors.New("empty group at " + strconv.Itoa(r.pos) + " position")
+	}
+	return str, r.expect(close)
+}
+
+// rawText - text in braces: \text{if}
+func (r *reader) rawText() (string, error) {
+	if err := r.expect("{"); err != nil {
+		return "", err
+	}
+	start, level := r.pos, 0
+	for ; r.pos < len(r.src); r.pos++ {
+		switch r.src[r.pos] {
+		case '{':
+			level++
+		case '}':
+			if level == 0 {
+				r.pos++
+				return string(r.src[start : r.pos-1]), nil
+			}
+			level--
+		}
+	}
+	return "", r.unexpected()
+}
+
+// argument - group in braces, single char or command
+func (r *reader) argument() (string, error) {
+	r.skipSpaces()
+	if r.pos == len(r.src) {
+		return "", r.unexpected()
+	}
+	c := r.src[r.pos]
+	switch {
+	case c == '{':
+		return r.group("{", "}")
+	case unicode.IsDigit(c) || unicode.IsLetter(c):
+		r.pos++
+		return string(c), nil
+	case c == '\\':
+		var b infix.Builder
+		if err := r.command(&b); err != nil {
+			return "", err
+		}
+		if b.Empty() {
+			return "", r.unexpected()
+		}
+		return b.String(), nil
+	}
+	return "", r.unexpected()
+}
+
+// subscript - index of the name: x_1, x_{max}, \beta_{\mathrm{in}}
+func (r *reader) subscript() (string, error) {
+	r.skipSpaces()
+	if r.pos == len(r.src) {
+		return "", r.unexpected()
+	}
+	if r.src[r.pos] != '{' {
+		c := r.src[r.pos]
+		if !unicode.IsDigit(c) && !unicode.IsLetter(c) {
+			return "", r.unexpected()
+		}
+		r.pos++
+		return string(c), nil
+	}
+	start := r.pos
+	text, err := r.rawText()
+	if err != nil {
+		return "", err
+	}
+	for _, cmd := range []string{"\\mathrm", "\\text", "\\operatorname", "\\mathit", "{", "}", "\\", " "} {
+		text = strings.ReplaceAll(text, cmd, "")
+	}
+	for _, c := range text {
+		if !unicode.IsDigit(c) && !unicode.IsLetter(c) && c != '_' {
+			return "", errors.New("incorrect subscript at " + strconv.Itoa(start) + " position")
+		}
+	}
+	return text, nil
+}
+
+// binary - LaTeX commands of the binary operators
+var binary = map[string]string{
+	"cdot": "*", "times": "*", "ast": "*", "div": "/", "bmod": "%", "mod": "%",
+	"le": "<=", "leq": "<=", "leqslant": "<=", "ge": ">=", "geq": ">=", "geqslant": ">=",
+	"neq": "!=", "ne": "!=", "lt": "<", "gt": ">",
+}
+
+func (r *reader) item(b *infix.Builder) error {
+	c := r.src[r.pos]
+	switch {
+	case unicode.IsDigit(c) || c == '.':
+		start := r.pos
+		for r.pos < len(r.src) && (unicode.IsDigit(r.src[r.pos]) || r.src[r.pos] == '.') {
+			r.pos++
+		}
+		b.Operand(string(r.src[start:r.pos]))
+		return nil
+
+	case unicode.IsLetter(c):
+		r.pos++
+		return r.name(b, string(c))
+
+	case strings.ContainsRune("+-*/<>=!", c):
+		for _, op := range []string{"<=", ">=", "!=", "==", "+", "-", "*", "/", "<", ">"} {
+			if r.at(op) {
+				r.pos += len(op)
+				b.Operator(op)
+				return nil
+			}
+		}
+		return r.unexpected()
+
+	case c == '(' || c == '[' || c == '{':
+		close := map[rune]string{'(': ")", '[': "]", '{': "}"}[c]
+		str, err := r.group(string(c), close)
+		if err != nil {
+			return err
+		}
+		b.Operand(infix.Group(str))
+		return nil
+
+	case c == '|':
+		str, err := r.group("|", "|")
+		if err != nil {
+			return err
+		}
+		b.Operand("abs(" + str + ")")
+		return nil
+
+	case c == '^':
+		r.pos++
+		exp, err := r.argument()
+		if err != nil {
+			return err
+		}
+		if err := b.Power(exp); err != nil {
+			return errors.New(err.Error() + " at " + strconv.Itoa(r.pos) + " position")
+		}
+		return nil
+
+	case c == '_':
+		r.pos++
+		index, err := r.subscript()
+		if err != nil {
+			return err
+		}
+		if err := b.Subscript(index); err != nil {
+			return errors.New(err.Error() + " at " + strconv.Itoa(r.pos) + " position")
+		}
+		return nil
+
+	case c == '\\':
+		return r.command(b)
+	}
+	return r.unexpected()
+}
+
+func (r *reader) command(b *infix.Builder) error {
+	start := r.pos
+	r.pos++
+	if r.pos == len(r.src) {
+		return r.unexpected()
+	}
+	name := string(r.src[r.pos])
+	r.pos++
+	if unicode.IsLetter([]rune(name)[0]) {
+		for r.pos < len(r.src) && unicode.IsLetter(r.src[r.pos]) {
+			name += string(r.src[r.pos])
+			r.pos++
+		}
+	}
+
+	if op, ok := binary[name]; ok {
+		b.Operator(op)
+		return nil
+	}
+	switch name {
+	case ",", ";", ":", "!", " ", "quad", "qquad":
+		return nil
+
+	case "frac", "dfrac", "tfrac":
+		num, err := r.argument()
+		if err != nil {
+			return err
+		}
+		den, err := r.argument()
+		if err != nil {
+			return err
+		}
+		b.Operand(infix.Group(infix.Group(num) + "/" + infix.Group(den)))
+		return nil
+
+	case "sqrt":
+		r.skipSpaces()
+		degree := ""
+		if r.at("[") {
+			var err error
+			if degree, err = r.group("[", "]"); err != nil {
+				return err
+			}
+		}
+		arg, err := r.argument()
+		if err != nil {
+			return err
+		}
+		if degree != "" {
+			b.Operand(infix.Group(infix.Group(arg) + "^(1/" + infix.Group(degree) + ")"))
+		} else {
+			b.Operand("sqrt(" + arg + ")")
+		}
+		return nil
+
+	case "left":
+		return r.left(b)
+
+	case "lvert", "vert":
+		str, err := r.sequence("\\rvert", "\\vert")
+		if err != nil {
+			return err
+		}
+		if r.at("\\rvert") {
+			err = r.expect("\\rvert")
+		} else {
+			err = r.expect("\\vert")
+		}
+		if err != nil {
+			return err
+		}
+		b.Operand("abs(" + str + ")")
+		return nil
+
+	case "pi":
+		b.Operand(strconv.FormatFloat(math.Pi, 'g', -1, 64))
+		return nil
+
+	case "infty":
+		b.Operand("inf")
+		return nil
+
+	case "mathrm", "operatorname", "mathit", "mathbf", "textrm":
+		text, err := r.rawText()
+		if err != nil {
+			return err
+		}
+		return r.name(b, strings.TrimSpace(text))
+
+	case "text":
+		text, err := r.rawText()
+		if err != nil {
+			return err
+		}
+		if !infix.IsText(text) {
+			return errors.New("incorrect text '" + text + "' at " + strconv.Itoa(r.pos) + " position")
+		}
+		b.Operand("\"" + text + "\"")
+		return nil
+
+	case "begin":
+		env, err := r.rawText()
+		if err != nil {
+			return err
+		}
+		if env != "cases" {
+			return errors.New("not supported LaTeX environment '" + env + "'")
+		}
+		return r.cases(b)
+	}
+
+	for _, greek := range Greek {
+		if greek == name {
+			return r.name(b, name)
+		}
+	}
+	if r.isFunction(name) {
+		return r.name(b, name)
+	}
+	return errors.New("not supported LaTeX command '\\" + name + "' at " + strconv.Itoa(start) + " position")
+}
+
+// left - \left( ... \right), \left| ... \right| is the absolute value
+func (r *reader) left(b *infix.Builder) error {
+	open, err := r.delimiter("\\lvert", "\\vert", "\\{", "|", "(", "[", ".")
+	if err != nil {
+		return err
+	}
+	str, err := r.sequence("\\right")
+	if err != nil {
+		return err
+	}
+	if err := r.expect("\\right"); err != nil {
+		return err
+	}
+	if _, err := r.delimiter("\\rvert", "\\vert", "\\}", "|", ")", "]", "."); err != nil {
+		return err
+	}
+	if open == "|" || strings.HasSuffix(open, "vert") {
+		b.Operand("abs(" + str + ")")
+	} else {
+		b.Operand(infix.Group(str))
+	}
+	return nil
+}
+
+// delimiter - one of the delimiters after \left or \right
+func (r *reader) delimiter(delimiters ...string) (string, error) {
+	r.skipSpaces()
+	for _, d := range delimiters {
+		if r.at(d) {
+			r.pos += len([]rune(d))
+			return d, nil
+		}
+	}
+	return "", r.unexpected()
+}
+
+// name - variable or call of the registered function: \operatorname{f}\left(x, y\right), \sin x
+func (r *reader) name(b *infix.Builder, name string) error {
+	if !infix.IsIdentifier(name) {
+		return errors.New("incorrect name '" + name + "' at " + strconv.Itoa(r.pos) + " position")
+	}
+	if !r.isFunction(name) {
+		b.Operand(name)
+		return nil
+	}
+	r.skipSpaces()
+	var args []string
+	switch {
+	case r.at("(") || r.at("\\left("):
+		close := ")"
+		if r.at("\\left(") {
+			r.pos += len("\\left(")
+			close = "\\right"
+		} else {
+			r.pos++
+		}
+		for {
+			arg, err := r.sequence(",", close)
+			if err != nil {
+				return err
+			}
+			if arg != "" || r.at(",") {
+				args = append(args, arg)
+			}
+			if r.at(",") {
+				r.pos++
+				continue
+			}
+			if err := r.expect(close); err != nil {
+				return err
+			}
+			if close == "\\right" {
+				if err := r.expect(")"); err != nil {
+					return err
+				}
+			}
+			break
+		}
+	default:
+		arg, err := r.argument()
+		if err != nil {
+			return err
+		}
+		args = append(args, arg)
+	}
+	b.Operand(name + "(" + strings.Join(args, ", ") + ")")
+	return nil
+}
+
+// cases - rows 'value & \text{if } condition' and optional 'value & \text{otherwise}' are the piecewise function
+func (r *reader) cases(b *infix.Builder) error {
+	var args []string
+	otherwise := ""
+	for {
+		value, err := r.sequence("&", "\\\\", "\\end")
+		if err != nil {
+			return err
+		}
+		cond := ""
+		if r.at("&") {
+			r.pos++
+			r.skipSpaces()
+			if r.at("\\text") {
+				r.pos += len("\\text")
+				text, err := r.rawText()
+				if err != nil {
+					return err
+				}
+				text = strings.TrimSpace(text)
+				if text == "otherwise" || text == "else" {
+					text = ""
+					otherwise = value
+					value = ""
+				}
+				cond = strings.TrimSpace(strings.TrimPrefix(text, "if"))
+			}
+			rest, err := r.sequence("\\\\", "\\end")
+			if err != nil {
+				return err
+			}
+			cond = strings.TrimSpace(cond + " " + rest)
+		}
+		if value != "" {
+			if cond == "" {
+				otherwise = value
+			} else {
+				args = append(args, cond, value)
+			}
+		}
+		if r.at("\\\\") {
+			r.pos += 2
+			continue
+		}
+		break
+	}
+	if err := r.expect("\\end"); err != nil {
+		return err
+	}
+	if env, err := r.rawText(); err != nil || env != "cases" {
+		return errors.New("incorrect end of cases environment")
+	}
+	if otherwise != "" {
+		args = append(args, otherwise)
+	}
+	if len(args) < 2 {
+		return errors.New("empty cases environment")
+	}
+	b.Operand("piecewise(" + strings.Join(args, ", ") + ")")
+	return nil
+}
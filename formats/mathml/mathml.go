@@ -0,0 +1,420 @@
+package mathml
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/overseven/go-math-expression-parser/formats/infix"
+)
+
+// element - node of the MathML document
+type element struct {
+	name     string
+	attrs    map[string]string
+	text     string
+	children []*element
+}
+
+// entities - named characters of MathML, which aren't defined in XML
+var entities = map[string]string{
+	"InvisibleTimes": "⁢", "it": "⁢", "ApplyFunction": "⁡", "af": "⁡",
+	"minus": "−", "times": "×", "divide": "÷", "sdot": "⋅", "middot": "·", "le": "≤", "leq": "≤", "ge": "≥",
+	"geq": "≥", "ne": "≠", "pi": "π", "lpar": "(", "rpar": ")", "verbar": "|", "vert": "|", "comma": ",",
+}
+
+// operators - MathML operators and their equivalents of the parser
+var operators = map[string]string{
+	"+": "+", "-": "-", "−": "-", "*": "*", "×": "*", "·": "*", "⋅": "*", "/": "/", "÷": "/", "%": "%",
+	"^": "^", "<": "<", ">": ">", "≤": "<=", "<=": "<=", "≥": ">=", ">=": ">=", "≠": "!=", "!=": "!=",
+	"==": "==",
+}
+
+// ignored - invisible operators, adjacent operands are multiplied anyway
+var ignored = map[string]bool{"⁢": true, "⁡": true, "⁣": true, "": true}
+
+// ToInfix - convert presentation MathML to the infix syntax of the parser.
+// Names for which isFunction returns true are function calls
+func ToInfix(src string, isFunction func(name string) bool) (string, error) {
+	root, err := parse(src)
+	if err != nil {
+		return "", err
+	}
+	c := converter{isFunction: isFunction}
+	if root.name == "math" {
+		return c.sequence(root.children)
+	}
+	return c.expr(root)
+}
+
+// parse - the tree of elements, namespaces are ignored
+func parse(src string) (*element, error) {
+	d := xml.NewDecoder(strings.NewReader(src))
+	d.Entity = entities
+	var stack []*element
+	var root *element
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("incorrect MathML: " + err.Error())
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &element{name: t.Name.Local, attrs: make(map[string]string)}
+			for _, attr := range t.Attr {
+				e.attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+			} else if root == nil {
+				root = e
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("empty MathML document")
+	}
+	return root, nil
+}
+
+type converter struct {
+	isFunction func(name string) bool
+}
+
+func text(e *element) string {
+	return strings.TrimSpace(e.text)
+}
+
+func isOperator(e *element, ops ...string) bool {
+	if e.name != "mo" {
+		return false
+	}
+	for _, op := range ops {
+		if text(e) == op {
+			return true
+		}
+	}
+	return false
+}
+
+// closing - index of the operator, which closes the parenthesis at the position
+func closing(elems []*element, i int, open, close string) (int, error) {
+	level := 0
+	for j := i; j < len(elems); j++ {
+		switch {
+		case open != close && isOperator(elems[j], open):
+			level++
+		case isOperator(elems[j], close):
+			if open == close && j == i {
+				continue
+			}
+			if open != close {
+				level--
+			}
+			if level == 0 || open == close {
+				return j, nil
+			}
+		}
+	}
+	return 0, errors.New("not closed '" + open + "' in MathML")
+}
+
+var brackets = map[string]string{"(": ")", "[": "]", "{": "}", "|": "|"}
+
+// sequence - operands and operators of the row, adjacent operands are multiplied
+func (c *converter) sequence(elems []*element) (string, error) {
+	var b infix.Builder
+	for i := 0; i < len(elems); i++ {
+		e := elems[i]
+		switch {
+		case e.name == "mo":
+			op := text(e)
+			if ignored[op] {
+				continue
+			}
+			if close, ok := brackets[op]; ok {
+				j, err := closing(elems, i, op, close)
+				if err != nil {
+					return "", err
+				}
+				inner, err := c.sequence(elems[i+1 : j])
+				if err != nil {
+					return "", err
+				}
+				if op == "|" {
+					b.Operand("abs(" + inner + ")")
+				} else {
+					b.Operand(infix.Group(inner))
+				}
+				i = j
+				continue
+			}
+			mapped, ok := operators[op]
+			if !ok {
+				return "", errors.New("not supported MathML operator '" + op + "'")
+			}
+			b.Operator(mapped)
+
+		case e.name == "mi" && c.isFunction(text(e)):
+			call, next, err := c.call(text(e), elems, i+1)
+			if err != nil {
+				return "", err
+			}
+			b.Operand(call)
+			i = next - 1
+
+		case e.name == "mspace" || e.name == "annotation" || e.name == "annotation-xml" || e.name == "none":
+
+		default:
+			operand, err := c.expr(e)
+			if err != nil {
+				return "", err
+			}
+			b.Operand(operand)
+		}
+	}
+	return b.String(), nil
+}
+
+// call - function with the arguments in parenthesis or with the single argument, returns the index after the call
+func (c *converter) call(name string, elems []*element, i int) (string, int, error) {
+	for i < len(elems) && elems[i].name == "mo" && ignored[text(elems[i])] {
+		i++
+	}
+	if i == len(elems) {
+		return "", 0, errors.New("function '" + name + "' without arguments in MathML")
+	}
+
+	var inner []*element
+	next := i + 1
+	switch e := elems[i]; {
+	case isOperator(e, "("):
+		j, err := closing(elems, i, "(", ")")
+		if err != nil {
+			return "", 0, err
+		}
+		inner, next = elems[i+1:j], j+1
+	case e.name == "mrow" && len(e.children) >= 2 && isOperator(e.children[0], "(") &&
+		isOperator(e.children[len(e.children)-1], ")"):
+		inner = e.children[1 : len(e.children)-1]
+	case e.name == "mfenced":
+		for k, child := range e.children {
+			if k > 0 {
+				inner = append(inner, &element{name: "mo", text: ","})
+			}
+			inner = append(inner, child)
+		}
+	default:
+		arg, err := c.expr(e)
+		if err != nil {
+			return "", 0, err
+		}
+		return name + "(" + arg + ")", next, nil
+	}
+
+	// split the arguments by the commas outside of the parenthesis
+	var args []string
+	start, level := 0, 0
+	for k, e := range inner {
+		switch {
+		case isOperator(e, "(", "[", "{"):
+			level++
+		case isOperator(e, ")", "]", "}"):
+			level--
+		case level == 0 && isOperator(e, ","):
+			arg, err := c.sequence(inner[start:k])
+			if err != nil {
+				return "", 0, err
+			}
+			args = append(args, arg)
+			start = k + 1
+		}
+	}
+	if len(inner) > 0 {
+		arg, err := c.sequence(inner[start:])
+		if err != nil {
+			return "", 0, err
+		}
+		args = append(args, arg)
+	}
+	return name + "(" + strings.Join(args, ", ") + ")", next, nil
+}
+
+// children - checks the count of the children of the element
+func children(e *element, count int) error {
+	if len(e.children) != count {
+		return errors.New("element '" + e.name + "' must have " + strconv.Itoa(count) + " children in MathML")
+	}
+	return nil
+}
+
+// name - identifier of mi, mn or the row of them: index of the subscript
+func name(e *element) (string, error) {
+	if e.name == "mi" || e.name == "mn" {
+		return text(e), nil
+	}
+	if e.name != "mrow" {
+		return "", errors.New("incorrect subscript element '" + e.name + "' in MathML")
+	}
+	str := ""
+	for _, child := range e.children {
+		part, err := name(child)
+		if err != nil {
+			return "", err
+		}
+		str += part
+	}
+	return str, nil
+}
+
+// expr - the operand of the element
+func (c *converter) expr(e *element) (string, error) {
+	switch e.name {
+	case "mi":
+		if text(e) == "π" {
+			return strconv.FormatFloat(math.Pi, 'g', -1, 64), nil
+		}
+		if !infix.IsIdentifier(text(e)) {
+			return "", errors.New("incorrect identifier '" + text(e) + "' in MathML")
+		}
+		return text(e), nil
+
+	case "mn":
+		if _, err := strconv.ParseFloat(text(e), 64); err != nil {
+			return "", errors.New("incorrect number '" + text(e) + "' in MathML")
+		}
+		return text(e), nil
+
+	case "mtext", "ms":
+		if !infix.IsText(text(e)) {
+			return "", errors.New("incorrect text '" + text(e) + "' in MathML")
+		}
+		return "\"" + text(e) + "\"", nil
+
+	case "mrow", "mstyle", "mpadded", "math":
+		str, err := c.sequence(e.children)
+		if err != nil {
+			return "", err
+		}
+		return infix.Group(str), nil
+
+	case "semantics":
+		if len(e.children) == 0 {
+			return "", errors.New("empty semantics element in MathML")
+		}
+		return c.expr(e.children[0])
+
+	case "mfenced":
+		open, ok := e.attrs["open"]
+		if !ok {
+			open = "("
+		}
+		args := make([]string, len(e.children))
+		for i, child := range e.children {
+			arg, err := c.expr(child)
+			if err != nil {
+				return "", err
+			}
+			args[i] = arg
+		}
+		if open == "|" {
+			return "abs(" + strings.Join(args, ", ") + ")", nil
+		}
+		if len(args) != 1 {
+			return "", errors.New("fenced list of " + strconv.Itoa(len(args)) + " elements without function in MathML")
+		}
+		return infix.Group(args[0]), nil
+
+	case "mfrac":
+		if err := children(e, 2); err != nil {
+			return "", err
+		}
+		num, err := c.expr(e.children[0])
+		if err != nil {
+			return "", err
+		}
+		den, err := c.expr(e.children[1])
+		if err != nil {
+			return "", err
+		}
+		return infix.Group(num + "/" + den), nil
+
+	case "msqrt":
+		str, err := c.sequence(e.children)
+		if err != nil {
+			return "", err
+		}
+		return "sqrt(" + str + ")", nil
+
+	case "mroot":
+		if err := children(e, 2); err != nil {
+			return "", err
+		}
+		base, err := c.expr(e.children[0])
+		if err != nil {
+			return "", err
+		}
+		degree, err := c.expr(e.children[1])
+		if err != nil {
+			return "", err
+		}
+		return infix.Group(base + "^(1/" + degree + ")"), nil
+
+	case "msup":
+		if err := children(e, 2); err != nil {
+			return "", err
+		}
+		base, err := c.expr(e.children[0])
+		if err != nil {
+			return "", err
+		}
+		exp, err := c.expr(e.children[1])
+		if err != nil {
+			return "", err
+		}
+		return infix.Group(infix.Group(base) + "^" + infix.Group(exp)), nil
+
+	case "msub", "msubsup":
+		count := 2
+		if e.name == "msubsup" {
+			count = 3
+		}
+		if err := children(e, count); err != nil {
+			return "", err
+		}
+		base, err := name(e.children[0])
+		if err != nil {
+			return "", err
+		}
+		index, err := name(e.children[1])
+		if err != nil {
+			return "", err
+		}
+		if !infix.IsIdentifier(base + "_" + index) {
+			return "", errors.New("incorrect subscript '" + base + "_" + index + "' in MathML")
+		}
+		if e.name == "msub" {
+			return base + "_" + index, nil
+		}
+		exp, err := c.expr(e.children[2])
+		if err != nil {
+			return "", err
+		}
+		return infix.Group(base + "_" + index + "^" + infix.Group(exp)), nil
+	}
+	return "", errors.New("not supported MathML element '" + e.name + "'")
+}
@@ -0,0 +1,88 @@
+package mathml_test
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+func TestParseMathML(t *testing.T) {
+	type TestData struct {
+		input  string
+		infix  string
+		output float64
+	}
+	data := []TestData{
+		{"<math><mfrac><mi>a</mi><mi>b</mi></mfrac><mo>+</mo><msqrt><msup><mi>x</mi><mn>2</mn></msup></msqrt></math>",
+			"a / b + sqrt(x ^ 2)", 4.5},
+		{"<math xmlns=\"http://www.w3.org/1998/Math/MathML\"><mn>2</mn><mo>&InvisibleTimes;</mo><mi>x</mi><mi>y</mi></math>",
+			"2 * x * y", 48},
+		{"<math><mn>2</mn><msup><mi>x</mi><mn>2</mn></msup><mo>&minus;</mo><mn>3</mn><mrow><mo>(</mo><mi>y</mi><mo>-</mo><mn>1</mn><mo>)</mo></mrow></math>",
+			"2 * (x ^ 2) - 3 * (y - 1)", 17},
+		{"<math><mo>|</mo><mi>b</mi><mo>-</mo><mi>a</mi><mo>|</mo><mo>&times;</mo><mo>(</mo><mi>a</mi><mo>+</mo><mn>1</mn><mo>)</mo></math>",
+			"abs(b - a) * (a + 1)", 2},
+		{"<math><mroot><mn>8</mn><mn>3</mn></mroot><mo>+</mo><mfenced open=\"|\" close=\"|\"><mrow><mi>a</mi><mo>-</mo><mi>b</mi></mrow></mfenced></math>",
+			"8 ^ (1 / 3) + abs(a - b)", 3},
+		{"<math><msub><mi>x</mi><mn>1</mn></msub><mo>·</mo><msub><mi>x</mi><mn>2</mn></msub><mo>÷</mo><mn>2</mn></math>",
+			"x_1 * x_2 / 2", 15},
+		{"<math><mi>foo</mi><mo>&ApplyFunction;</mo><mo>(</mo><mi>a</mi><mo>,</mo><mi>y</mi><mo>)</mo><mo>+</mo><mi>foo</mi><mfenced><mn>1</mn><mn>2</mn></mfenced></math>",
+			"foo(a, y) + foo(1, 2)", 10},
+		{"<math><mi>sqrt</mi><mi>x</mi><mo>+</mo><mi>abs</mi><mrow><mo>(</mo><mo>-</mo><mi>a</mi><mo>)</mo></mrow></math>",
+			"sqrt(x) + abs(-a)", 3},
+		{"<math><mi>a</mi><mo>&le;</mo><mi>b</mi></math>", "a <= b", 1},
+		{"<math><mn>2</mn><mi>&pi;</mi></math>", "2 * 3.141592653589793", 6.283185307179586},
+		{"<math><semantics><mrow><msubsup><mi>x</mi><mn>1</mn><mn>2</mn></msubsup></mrow><annotation encoding=\"TeX\">x_1^2</annotation></semantics></math>",
+			"(x_1 ^ 2)", 25},
+	}
+
+	p := parser.NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) { return args[0] + args[1], nil }, "foo")
+	vars := map[string]float64{"a": 1, "b": 2, "x": 4, "y": 6, "x_1": 5, "x_2": 6}
+	for _, d := range data {
+		exp, err := p.ParseMathML(d.input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		need, err := p.Parse(d.infix)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if exp.String() != need.String() {
+			t.Error("incorrect tree of '" + d.input + "': " + exp.String() + ", need: " + need.String())
+		}
+		res, err := exp.Evaluate(vars, p)
+		if err != nil {
+			t.Error(err)
+		}
+		if math.Abs(res-d.output) > 1e-9 {
+			t.Error("incorrect result of '" + d.input + "': " + strconv.FormatFloat(res, 'g', -1, 64))
+		}
+	}
+
+	for _, input := range []string{"", "<math><mi>x</mi>", "<math><mfrac><mi>a</mi></mfrac></math>",
+		"<math><mo>(</mo><mi>a</mi></math>", "<math><mo>&</mo></math>", "<math><mo>?</mo><mi>a</mi></math>",
+		"<math><mtable/></math>", "<math><mi>foo</mi></math>", "<math><mi>a</mi><mo>+</mo></math>",
+		"<math><mi>g(x) = x + 1</mi></math>", "<math><mi>x</mi><mo>=</mo><mn>3</mn></math>",
+		"<math><mn>1; b</mn></math>", "<math><msub><mi>x</mi><mi>a+b</mi></msub></math>"} {
+		if _, err := p.ParseMathML(input); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+	if _, ok := p.Definitions["g"]; ok {
+		t.Error("function is defined by MathML formula")
+	}
+
+	// the locale of the parser doesn't change the numbers and the arguments of MathML
+	p.Locale = parser.EuropeanLocale
+	exp, err := p.ParseMathML("<math><mfrac><mn>1.5</mn><mn>2</mn></mfrac><mo>+</mo><mi>foo</mi><mfenced><mn>1</mn><mn>2</mn></mfenced></math>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := exp.Evaluate(nil, p); err != nil || res != 3.75 {
+		t.Error("incorrect result of MathML with the locale: " + strconv.FormatFloat(res, 'g', -1, 64))
+	}
+}
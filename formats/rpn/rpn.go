@@ -0,0 +1,222 @@
+package rpn
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/overseven/go-math-expression-parser/funcs"
+	"github.com/overseven/go-math-expression-parser/funcs/userfunc"
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// UnaryPrefix - prefix of the unary operators, which differ from the binary ones: "x u-" is -x
+const UnaryPrefix = "u"
+
+// ArityMark - separator of the function name and the count of arguments,
+// it's written for the functions without fixed arity: "a b c max@3"
+const ArityMark = "@"
+
+// Grammar - operators and functions, which are known to the RPN reader
+type Grammar struct {
+	Functions    [funcs.LevelsOfOperators]map[string]funcs.FuncType
+	SpecialForms map[string]funcs.SpecialFormType
+	// Arity - count of arguments of the function, ok is false for variadic functions
+	Arity func(name string) (count int, ok bool)
+	// Variable - checks the name of variable, any name starting with a letter or '_' is a variable if it's nil
+	Variable func(name string) bool
+}
+
+// isName - the first rune is a letter or '_': names are functions, other operators are symbols
+func isName(op string) bool {
+	for _, c := range op {
+		return unicode.IsLetter(c) || c == '_'
+	}
+	return false
+}
+
+// Write - tokens of the expression in reverse Polish notation: (a + b) * 2 is "a b + 2 *".
+// Functions with the arity are written by the name, other functions with the count of arguments
+func Write(exp interfaces.Expression, arity func(name string) (int, bool)) ([]string, error) {
+	var tokens []string
+	var write func(exp interfaces.Expression) error
+	call := func(name string, args []interfaces.Expression) error {
+		for _, arg := range args {
+			if err := write(arg); err != nil {
+				return err
+			}
+		}
+		if count, ok := arity(name); ok && count == len(args) {
+			tokens = append(tokens, name)
+		} else {
+			tokens = append(tokens, name+ArityMark+strconv.Itoa(len(args)))
+		}
+		return nil
+	}
+	write = func(exp interfaces.Expression) error {
+		switch e := exp.(type) {
+		case *internal.Term:
+			if e.Val == "" {
+				tokens = append(tokens, "0")
+			} else {
+				tokens = append(tokens, e.Val)
+			}
+			return nil
+
+		case *internal.Node:
+			if err := write(e.LExp); err != nil {
+				return err
+			}
+			if err := write(e.RExp); err != nil {
+				return err
+			}
+			tokens = append(tokens, e.Op)
+			return nil
+
+		case *internal.Unary:
+			if isName(e.Op) {
+				return call(e.Op, []interfaces.Expression{e.Exp})
+			}
+			if err := write(e.Exp); err != nil {
+				return err
+			}
+			tokens = append(tokens, UnaryPrefix+e.Op)
+			return nil
+
+		case interfaces.Function:
+			return call(e.GetOperation(), e.GetArgs())
+		}
+		return errors.New("not supported node '" + exp.String() + "' in RPN")
+	}
+	if err := write(exp); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Split - tokens of the string separated by spaces, quoted strings may contain spaces: d "km / h" to
+func Split(src string) ([]string, error) {
+	var tokens []string
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		if runes[i] == '"' {
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				i++
+			}
+			if i == len(runes) {
+				return nil, errors.New("incorrect string literal at " + strconv.Itoa(start) + " position")
+			}
+			i++
+		} else {
+			for i < len(runes) && !unicode.IsSpace(runes[i]) {
+				i++
+			}
+		}
+		tokens = append(tokens, string(runes[start:i]))
+	}
+	return tokens, nil
+}
+
+// Read - the expression tree of the tokens in reverse Polish notation
+func Read(tokens []string, g Grammar) (interfaces.Expression, error) {
+	var stack []interfaces.Expression
+	pop := func(count int, tok string, i int) ([]interfaces.Expression, error) {
+		if len(stack) < count {
+			return nil, errors.New("not enough operands for '" + tok + "' at " + strconv.Itoa(i+1) + " token")
+		}
+		args := append([]interfaces.Expression{}, stack[len(stack)-count:]...)
+		stack = stack[:len(stack)-count]
+		return args, nil
+	}
+
+	for i, tok := range tokens {
+		name, count, explicit := tok, 0, false
+		if j := strings.LastIndex(tok, ArityMark); j > 0 && isName(tok) {
+			n, err := strconv.Atoi(tok[j+1:])
+			if err != nil || n < 0 {
+				return nil, errors.New("incorrect count of arguments '" + tok + "' at " + strconv.Itoa(i+1) + " token")
+			}
+			name, count, explicit = tok[:j], n, true
+		}
+		_, isFunc := g.Functions[0][name]
+		if _, ok := g.SpecialForms[name]; ok {
+			isFunc = true
+		}
+		isFunc = isFunc && isName(name)
+
+		switch {
+		case isFunc:
+			if !explicit {
+				n, ok := g.Arity(name)
+				if !ok {
+					return nil, errors.New("unknown count of arguments of '" + name + "' function at " +
+						strconv.Itoa(i+1) + " token, write it as " + name + ArityMark + "N")
+				}
+				count = n
+			}
+			args, err := pop(count, tok, i)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, &userfunc.Func{Op: name, Args: args})
+
+		case explicit:
+			return nil, errors.New("unknown function '" + name + "' at " + strconv.Itoa(i+1) + " token")
+
+		case isBinary(tok, g):
+			args, err := pop(2, tok, i)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, &internal.Node{Op: tok, LExp: args[0], RExp: args[1]})
+
+		case strings.HasPrefix(tok, UnaryPrefix) && isUnary(tok[len(UnaryPrefix):], g):
+			args, err := pop(1, tok, i)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, &internal.Unary{Op: tok[len(UnaryPrefix):], Exp: args[0]})
+
+		case internal.IsQuoted(tok) || isNumber(tok) || isName(tok) && (g.Variable == nil || g.Variable(tok)):
+			stack = append(stack, &internal.Term{Val: tok})
+
+		default:
+			return nil, errors.New("unexpected '" + tok + "' at " + strconv.Itoa(i+1) + " token")
+		}
+	}
+
+	switch len(stack) {
+	case 0:
+		return &internal.Term{Val: "0"}, nil
+	case 1:
+		return stack[0], nil
+	}
+	return nil, errors.New(strconv.Itoa(len(stack)) + " values are left after RPN expression, need: 1")
+}
+
+func isBinary(op string, g Grammar) bool {
+	for i := 1; i < len(g.Functions); i++ {
+		if _, ok := g.Functions[i][op]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isUnary(op string, g Grammar) bool {
+	_, ok := g.Functions[0][op]
+	return ok && !isName(op)
+}
+
+func isNumber(val string) bool {
+	_, err := strconv.ParseFloat(val, 64)
+	return err == nil
+}
@@ -0,0 +1,94 @@
+package rpn_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/formats/rpn"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+func TestWrite(t *testing.T) {
+	type TestData struct {
+		input  string
+		output string
+	}
+	data := []TestData{
+		{"(a + b) * 2", "a b + 2 *"},
+		{"a - b - c", "a b - c -"},
+		{"a - (b - c)", "a b c - -"},
+		{"-x ^ 2", "x 2 ^ u-"},
+		{"sqrt(x) + abs(-y)", "x sqrt y u- abs +"},
+		{"√x", "x sqrt"},
+		{"foo(1, 2, x) <= 3", "1 2 x foo@3 3 <="},
+		{"if(x > 0, 1, piecewise(x < 0, -1, 0))", "x 0 > 1 x 0 < 1 u- 0 piecewise@3 if"},
+		{"sq(x + 1)", "x 1 + sq"},
+		{"to(d, \"km / h\")", "d \"km / h\" to"},
+		{"", "0"},
+	}
+	p := parser.NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) { return 1, nil }, "foo")
+	p.Parse("sq(x) = x * x")
+	for _, d := range data {
+		exp, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		tokens, err := p.ToRPN(exp)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if res := strings.Join(tokens, " "); res != d.output {
+			t.Error("incorrect RPN of '" + d.input + "': " + res + ", need: " + d.output)
+		}
+		parsed, err := p.ParseRPN(d.output)
+		if err != nil {
+			t.Error("can't parse RPN of '" + d.input + "': " + err.Error())
+			continue
+		}
+		// prefix operators of functions are calls in RPN
+		need, _ := p.Parse(strings.ReplaceAll(d.input, "√x", "sqrt(x)"))
+		if parsed.String() != need.String() {
+			t.Error("incorrect tree of '" + d.output + "': " + parsed.String() + ", need: " + need.String())
+		}
+	}
+
+	for _, input := range []string{"a = 1; a", "let x = 1 in x"} {
+		exp, _ := p.Parse(input)
+		if _, err := p.ToRPN(exp); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	p := parser.NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) { return args[0] + args[1], nil }, "foo")
+	exp, err := p.ParseRPN("  3 4 foo@2\tx u- * ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := p.Evaluate(map[string]float64{"x": 2}); err != nil || res != -14 || exp != p.Expression {
+		t.Error("incorrect evaluation of RPN")
+	}
+
+	p.Arities["foo"] = 2
+	if _, err := p.ParseRPN("1 2 foo"); err != nil {
+		t.Error(err)
+	}
+
+	for _, input := range []string{"1 +", "1 2", "1 2 3 foo", "x sqrt@2", "bar@1", "1 2 max@x", "1 u*", "1 ?",
+		"\"abc", "1 sq@1", "1 2 3 if@2",
+		"a+b", "1 x*y +", "b a-1 *"} {
+		if _, err := p.ParseRPN(input); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+
+	tokens, err := rpn.Split(`d "km / h" to  "" x`)
+	if err != nil || len(tokens) != 5 || tokens[1] != `"km / h"` || tokens[3] != `""` {
+		t.Error("incorrect split: " + strings.Join(tokens, "|"))
+	}
+}
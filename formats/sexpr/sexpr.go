@@ -0,0 +1,288 @@
+package sexpr
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/overseven/go-math-expression-parser/funcs/userfunc"
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// Write - canonical S-expression of the tree. It's the String() notation without padding spaces:
+// (+ a (f (b, 2))), unary operators have one operand: (- x), statements are separated by "; "
+func Write(exp interfaces.Expression) string {
+	switch e := exp.(type) {
+	case *internal.Term:
+		if e.Val == "" {
+			return "0"
+		}
+		return e.Val
+
+	case *internal.Node:
+		return "(" + e.Op + " " + Write(e.LExp) + " " + Write(e.RExp) + ")"
+
+	case *internal.Unary:
+		return "(" + e.Op + " " + Write(e.Exp) + ")"
+
+	case interfaces.Function:
+		args := make([]string, len(e.GetArgs()))
+		for i, arg := range e.GetArgs() {
+			args[i] = Write(arg)
+		}
+		return "(" + e.GetOperation() + " (" + strings.Join(args, ", ") + "))"
+
+	case *internal.Let:
+		return "(let " + e.Name + " " + Write(e.Value) + " " + Write(e.Body) + ")"
+
+	case *internal.Assign:
+		return "(= " + e.Name + " " + Write(e.Exp) + ")"
+
+	case *internal.Script:
+		stmts := make([]string, len(e.Stmts))
+		for i, stmt := range e.Stmts {
+			stmts[i] = Write(stmt)
+		}
+		return strings.Join(stmts, "; ")
+	}
+	return exp.String()
+}
+
+// token of the S-expression: parenthesis, separator ',' or ';', atom or quoted string
+type token struct {
+	val string
+	pos int
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.ContainsRune("(),;", c):
+			tokens = append(tokens, token{string(c), i})
+			i++
+		case c == '"':
+			start := i
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+			}
+			if i == len(runes) {
+				return nil, errors.New("incorrect string literal at " + strconv.Itoa(start) + " position")
+			}
+			i++
+			tokens = append(tokens, token{string(runes[start:i]), start})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("(),;\"", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{string(runes[start:i]), start})
+		}
+	}
+	return tokens, nil
+}
+
+// Read - the tree of the S-expression, which is written by Write() or by String() of the nodes:
+// ( + a ( f ( b,2 ) ) ). Statements of the script are separated by ';'
+func Read(src string) (interfaces.Expression, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	r := reader{tokens: tokens}
+	if len(tokens) == 0 {
+		return &internal.Term{Val: "0"}, nil
+	}
+
+	var stmts []interfaces.Expression
+	for {
+		stmt, err := r.expr()
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+		if r.peek().val != ";" {
+			break
+		}
+		r.pos++
+	}
+	if r.pos != len(r.tokens) {
+		return nil, r.unexpected()
+	}
+	if len(stmts) == 1 {
+		return stmts[0], nil
+	}
+	return &internal.Script{Stmts: stmts}, nil
+}
+
+type reader struct {
+	tokens []token
+	pos    int
+}
+
+func (r *reader) peek() token {
+	if r.pos < len(r.tokens) {
+		return r.tokens[r.pos]
+	}
+	return token{}
+}
+
+func (r *reader) unexpected() error {
+	if r.pos >= len(r.tokens) {
+		return errors.New("unexpected end of S-expression")
+	}
+	tok := r.tokens[r.pos]
+	return errors.New("unexpected '" + tok.val + "' at " + strconv.Itoa(tok.pos) + " position")
+}
+
+// atom - the name, the number or the quoted string
+func (r *reader) atom() (string, error) {
+	tok := r.peek()
+	if tok.val == "" || strings.Contains("(),;", tok.val) {
+		return "", r.unexpected()
+	}
+	r.pos++
+	return tok.val, nil
+}
+
+func (r *reader) expect(val string) error {
+	if r.peek().val != val {
+		return r.unexpected()
+	}
+	r.pos++
+	return nil
+}
+
+func (r *reader) expr() (interfaces.Expression, error) {
+	if r.peek().val != "(" {
+		val, err := r.atom()
+		if err != nil {
+			return nil, err
+		}
+		return &internal.Term{Val: val}, nil
+	}
+	r.pos++
+	head, err := r.atom()
+	if err != nil {
+		return nil, err
+	}
+
+	var exp interfaces.Expression
+	switch {
+	case head == "let":
+		name, err := r.atom()
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.expr()
+		if err != nil {
+			return nil, err
+		}
+		body, err := r.expr()
+		if err != nil {
+			return nil, err
+		}
+		exp = &internal.Let{Name: name, Value: value, Body: body}
+
+	case head == "=":
+		name, err := r.atom()
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.expr()
+		if err != nil {
+			return nil, err
+		}
+		exp = &internal.Assign{Name: name, Exp: value}
+
+	case r.isArgs():
+		args, err := r.args()
+		if err != nil {
+			return nil, err
+		}
+		exp = &userfunc.Func{Op: head, Args: args}
+
+	default:
+		first, err := r.expr()
+		if err != nil {
+			return nil, err
+		}
+		if r.peek().val == ")" {
+			exp = &internal.Unary{Op: head, Exp: first}
+			break
+		}
+		second, err := r.expr()
+		if err != nil {
+			return nil, err
+		}
+		exp = &internal.Node{Op: head, LExp: first, RExp: second}
+	}
+	if err := r.expect(")"); err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
+
+// isArgs - checks that the list at the position is the arguments of the function, not an operand:
+// it's empty, it has one element or the elements are separated by commas
+func (r *reader) isArgs() bool {
+	if r.peek().val != "(" {
+		return false
+	}
+	level, count := 0, 0
+	for i := r.pos; i < len(r.tokens); i++ {
+		switch r.tokens[i].val {
+		case "(":
+			level++
+			if level == 2 {
+				count++
+			}
+		case ")":
+			level--
+			if level == 0 {
+				return count <= 1
+			}
+		case ",":
+			if level == 1 {
+				return true
+			}
+		default:
+			if level == 1 {
+				count++
+			}
+		}
+	}
+	return false
+}
+
+// args - the list of arguments separated by commas
+func (r *reader) args() ([]interfaces.Expression, error) {
+	if err := r.expect("("); err != nil {
+		return nil, err
+	}
+	var args []interfaces.Expression
+	if r.peek().val == ")" {
+		r.pos++
+		return args, nil
+	}
+	for {
+		arg, err := r.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if r.peek().val != "," {
+			break
+		}
+		r.pos++
+	}
+	if err := r.expect(")"); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
@@ -0,0 +1,66 @@
+package sexpr_test
+
+import (
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/formats/sexpr"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+func TestReadWrite(t *testing.T) {
+	corpus := []string{
+		"", "x", "x*(sqrt(y)+1)", "(доход-расход)*налог", "f1(1) + f1()", "f1(f1(), -x, (a + b))", "2^3-10",
+		"-(a + b)", "- -a", "√x + -sqrt(x)", "abs(-(a * b))", "tax = price * 0.2; total = price + tax; total * qty",
+		"let m = price - cost in m * qty / (m + fee)", "if(x > 0, sqrt(x), 0)", "to(d, \"km / h, m\")",
+		"piecewise(x < 0, -1, x == 0, 0, 1)", "1.5e-3 * x",
+	}
+	p := parser.NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) { return 1, nil }, "f1")
+	for _, input := range corpus {
+		exp, err := p.Parse(input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		for _, str := range []string{exp.String(), sexpr.Write(exp)} {
+			read, err := sexpr.Read(str)
+			if err != nil {
+				t.Error("can't read '" + str + "': " + err.Error())
+				continue
+			}
+			if read.String() != exp.String() || sexpr.Write(read) != sexpr.Write(exp) {
+				t.Error("incorrect tree of '" + str + "': " + read.String())
+			}
+		}
+	}
+
+	type TestData struct {
+		input  string
+		output string
+	}
+	data := []TestData{
+		{"a + f1(b, 2)", "(+ a (f1 (b, 2)))"},
+		{"-x * f1()", "(- (* x (f1 ())))"},
+		{"√x", "(sqrt (x))"},
+		{"a = 1; let b = a in b", "(= a 1); (let b a b)"},
+	}
+	for _, d := range data {
+		exp, _ := p.Parse(d.input)
+		if res := p.SExpr(exp); res != d.output {
+			t.Error("incorrect S-expression of '" + d.input + "': " + res + ", need: " + d.output)
+		}
+	}
+
+	if exp, err := p.ParseSExpr("( * ( + 1 2 ) ( f1 ( 3,4 ) ) )"); err != nil {
+		t.Error(err)
+	} else if res, err := p.Evaluate(nil); err != nil || res != 3 || exp != p.Expression {
+		t.Error("incorrect evaluation of S-expression")
+	}
+
+	for _, input := range []string{"(", "( + 1 2", "( + 1 2 3 )", "()", "( + )", "1 2", "( f1 ( 1, ) )",
+		"( let x 1 )", "( = )", "\"abc", "( ( + 1 2 ) 3 )", "1;", ")", "( unknown ( 1 ) )", "( ? 1 2 )"} {
+		if _, err := p.ParseSExpr(input); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+}
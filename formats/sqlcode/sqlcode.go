@@ -0,0 +1,325 @@
+package sqlcode
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// Dialect - SQL of the operators and the functions, which differ between databases.
+// The result of Pow and Mod must bind at least as tight as the multiplication
+type Dialect struct {
+	// Pow - power a ^ b
+	Pow func(a, b string) string
+	// Mod - remainder of the division of integer parts, like the % operator of the evaluator
+	Mod func(a, b string) string
+	// Function - call of the built-in or user function, ok is false if the function isn't supported
+	Function func(name string, args []string) (sql string, ok bool)
+}
+
+// Standard - ANSI SQL: POWER(a, b), MOD(TRUNC(a), TRUNC(b)), SQRT(x), ABS(x)
+var Standard = Dialect{
+	Pow: func(a, b string) string {
+		return "POWER(" + a + ", " + b + ")"
+	},
+	Mod: func(a, b string) string {
+		return "MOD(TRUNC(" + a + "), TRUNC(" + b + "))"
+	},
+	Function: Function,
+}
+
+// Postgres - PostgreSQL: a ^ b, TRUNC(a)::bigint % TRUNC(b)::bigint
+var Postgres = Dialect{
+	Pow: func(a, b string) string {
+		return a + " ^ " + b
+	},
+	Mod: func(a, b string) string {
+		return "TRUNC(" + a + ")::bigint % TRUNC(" + b + ")::bigint"
+	},
+	Function: Function,
+}
+
+// Function - SQL of the built-in functions sqrt and abs, it's used by the dialects
+func Function(name string, args []string) (string, bool) {
+	switch name {
+	case "sqrt", "abs":
+		if len(args) != 1 {
+			return "", false
+		}
+		return strings.ToUpper(name) + "(" + args[0] + ")", true
+	}
+	return "", false
+}
+
+// QuoteIdentifier - the name in double quotes: "order.total"
+func QuoteIdentifier(name string) string {
+	return "\"" + strings.ReplaceAll(name, "\"", "\"\"") + "\""
+}
+
+// Options - dialect of the SQL and names of the columns
+type Options struct {
+	// Dialect - the hooks, which aren't set, are taken from Standard
+	Dialect Dialect
+	// Column - SQL of the variable, QuoteIdentifier is used if it's nil. The columns must be floating point:
+	// the division of integer columns is the integer division in SQL, so they are cast by this function
+	Column func(name string) string
+}
+
+// SQL precedence of the operators, primary expressions are literals, columns, calls and CASE
+const (
+	precSum     = 1
+	precProduct = 2
+	precUnary   = 3
+	precPrimary = 4
+)
+
+// code - SQL expression and its precedence
+type code struct {
+	str  string
+	prec int
+}
+
+// SQL operators of the comparisons
+var comparisons = map[string]string{"<": "<", "<=": "<=", ">": ">", ">=": ">=", "==": "=", "!=": "<>"}
+
+type writer struct {
+	dialect Dialect
+	column  func(name string) string
+	// values of the let-bindings, they are substituted to the body
+	scope map[string]code
+}
+
+// Write - SQL expression of the tree: "price" * "qty" - POWER("x", 2.0).
+// Comparisons are CASE WHEN ... THEN 1.0 ELSE 0.0 END, if and piecewise are CASE expressions,
+// piecewise without the default value is NULL if no condition is satisfied, let-bindings are substituted.
+// The numbers are floating point literals, but the integer columns must be cast by Options.Column.
+// Strings, iferror, assignments and scripts aren't supported
+func Write(exp interfaces.Expression, opts Options) (string, error) {
+	w := writer{dialect: opts.Dialect, column: opts.Column, scope: make(map[string]code)}
+	if w.dialect.Pow == nil {
+		w.dialect.Pow = Standard.Pow
+	}
+	if w.dialect.Mod == nil {
+		w.dialect.Mod = Standard.Mod
+	}
+	if w.dialect.Function == nil {
+		w.dialect.Function = Standard.Function
+	}
+	if w.column == nil {
+		w.column = QuoteIdentifier
+	}
+	res, err := w.expr(exp)
+	if err != nil {
+		return "", err
+	}
+	return res.str, nil
+}
+
+// paren - the operand in parenthesis, if it binds weaker than the operator
+func paren(c code, prec int) string {
+	if c.prec < prec {
+		return "(" + c.str + ")"
+	}
+	return c.str
+}
+
+// number - SQL literal, it always contains the point or the exponent: 1 / 2 isn't the integer division
+func number(val float64) (code, error) {
+	if math.IsNaN(val) || math.IsInf(val, 0) {
+		return code{}, errors.New("not supported number '" + strconv.FormatFloat(val, 'g', -1, 64) + "' in SQL")
+	}
+	str := strconv.FormatFloat(val, 'g', -1, 64)
+	if !strings.ContainsAny(str, ".e") {
+		str += ".0"
+	}
+	if val < 0 {
+		return code{str, precUnary}, nil
+	}
+	return code{str, precPrimary}, nil
+}
+
+func (w *writer) expr(exp interfaces.Expression) (code, error) {
+	switch e := exp.(type) {
+	case *internal.Term:
+		if e.Val == "" {
+			return code{"0.0", precPrimary}, nil
+		}
+		if val, err := strconv.ParseFloat(e.Val, 64); err == nil {
+			return number(val)
+		}
+		if internal.IsQuoted(e.Val) {
+			return code{}, errors.New("not supported string " + e.Val + " in SQL")
+		}
+		if c, ok := w.scope[e.Val]; ok {
+			return c, nil
+		}
+		return code{w.column(e.Val), precPrimary}, nil
+
+	case *internal.Unary:
+		switch e.Op {
+		case "-":
+			x, err := w.expr(e.Exp)
+			if err != nil {
+				return code{}, err
+			}
+			// "--" starts the comment
+			if x.prec < precUnary || strings.HasPrefix(x.str, "-") {
+				return code{"-(" + x.str + ")", precUnary}, nil
+			}
+			return code{"-" + x.str, precUnary}, nil
+		case "+":
+			return w.expr(e.Exp)
+		}
+		return w.call(e.Op, []interfaces.Expression{e.Exp})
+
+	case *internal.Node:
+		return w.node(e)
+
+	case interfaces.Function:
+		return w.call(e.GetOperation(), e.GetArgs())
+
+	case *internal.Let:
+		value, err := w.expr(e.Value)
+		if err != nil {
+			return code{}, err
+		}
+		if value.prec < precPrimary {
+			value = code{"(" + value.str + ")", precPrimary}
+		}
+		saved, shadowed := w.scope[e.Name]
+		w.scope[e.Name] = value
+		res, err := w.expr(e.Body)
+		if shadowed {
+			w.scope[e.Name] = saved
+		} else {
+			delete(w.scope, e.Name)
+		}
+		return res, err
+
+	case *internal.Assign:
+		return code{}, errors.New("not supported assignment to '" + e.Name + "' in SQL")
+
+	case *internal.Script:
+		return code{}, errors.New("not supported script in SQL")
+	}
+	return code{}, errors.New("not supported node '" + exp.String() + "' in SQL")
+}
+
+func (w *writer) node(n *internal.Node) (code, error) {
+	if _, ok := comparisons[n.Op]; ok {
+		cond, err := w.condition(n)
+		if err != nil {
+			return code{}, err
+		}
+		return code{"CASE WHEN " + cond + " THEN 1.0 ELSE 0.0 END", precPrimary}, nil
+	}
+
+	x, err := w.expr(n.LExp)
+	if err != nil {
+		return code{}, err
+	}
+	y, err := w.expr(n.RExp)
+	if err != nil {
+		return code{}, err
+	}
+	switch n.Op {
+	case "+", "-":
+		return code{paren(x, precSum) + " " + n.Op + " " + paren(y, precSum+1), precSum}, nil
+	case "*", "/":
+		return code{paren(x, precProduct) + " " + n.Op + " " + paren(y, precProduct+1), precProduct}, nil
+	case "^":
+		return code{w.dialect.Pow(paren(x, precPrimary), paren(y, precPrimary)), precProduct}, nil
+	case "%":
+		return code{w.dialect.Mod(paren(x, precPrimary), paren(y, precPrimary)), precProduct}, nil
+	}
+	return code{}, errors.New("not supported binary operation '" + n.Op + "' in SQL")
+}
+
+// condition - SQL condition of the expression, non-zero value is true
+func (w *writer) condition(exp interfaces.Expression) (string, error) {
+	if n, ok := exp.(*internal.Node); ok {
+		if op, ok := comparisons[n.Op]; ok {
+			x, err := w.expr(n.LExp)
+			if err != nil {
+				return "", err
+			}
+			y, err := w.expr(n.RExp)
+			if err != nil {
+				return "", err
+			}
+			return x.str + " " + op + " " + y.str, nil
+		}
+	}
+	x, err := w.expr(exp)
+	if err != nil {
+		return "", err
+	}
+	return x.str + " <> 0", nil
+}
+
+func (w *writer) call(name string, args []interfaces.Expression) (code, error) {
+	switch name {
+	case "if", "piecewise", "case":
+		if name == "if" && len(args) != 3 || len(args) < 2 {
+			return code{}, errors.New("incorrect count of args for '" + name + "' function: " + strconv.Itoa(len(args)))
+		}
+		var sb strings.Builder
+		sb.WriteString("CASE")
+		for i := 0; i+1 < len(args); i += 2 {
+			cond, err := w.condition(args[i])
+			if err != nil {
+				return code{}, err
+			}
+			val, err := w.expr(args[i+1])
+			if err != nil {
+				return code{}, err
+			}
+			sb.WriteString(" WHEN " + cond + " THEN " + val.str)
+		}
+		if len(args)%2 == 1 {
+			val, err := w.expr(args[len(args)-1])
+			if err != nil {
+				return code{}, err
+			}
+			sb.WriteString(" ELSE " + val.str)
+		}
+		sb.WriteString(" END")
+		return code{sb.String(), precPrimary}, nil
+
+	case "coalesce":
+		// NULL is the missing value of SQL, like NaN of the evaluator
+		if len(args) < 1 {
+			return code{}, errors.New("incorrect count of args for 'coalesce' function: 0")
+		}
+		strs, err := w.args(args)
+		if err != nil {
+			return code{}, err
+		}
+		return code{"COALESCE(" + strings.Join(strs, ", ") + ")", precPrimary}, nil
+	}
+
+	strs, err := w.args(args)
+	if err != nil {
+		return code{}, err
+	}
+	if sql, ok := w.dialect.Function(name, strs); ok {
+		return code{sql, precPrimary}, nil
+	}
+	return code{}, errors.New("not supported function '" + name + "' with " + strconv.Itoa(len(args)) + " args in SQL")
+}
+
+func (w *writer) args(args []interfaces.Expression) ([]string, error) {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		c, err := w.expr(arg)
+		if err != nil {
+			return nil, err
+		}
+		strs[i] = c.str
+	}
+	return strs, nil
+}
@@ -0,0 +1,131 @@
+package sqlcode_test
+
+import (
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/formats/sqlcode"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+func TestWrite(t *testing.T) {
+	p := parser.NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) { return 1, nil }, "foo")
+	type TestData struct {
+		input    string
+		standard string
+		postgres string
+	}
+	data := []TestData{
+		{"price * qty - x^2", `"price" * "qty" - POWER("x", 2.0)`, `"price" * "qty" - "x" ^ 2.0`},
+		{"a - (b - c) / (d * e)", `"a" - ("b" - "c") / ("d" * "e")`, `"a" - ("b" - "c") / ("d" * "e")`},
+		{"-(a + b) * -c", `-(("a" + "b") * -"c")`, `-(("a" + "b") * -"c")`},
+		{"- -a", `-(-"a")`, `-(-"a")`},
+		{"c * (a % b)", `"c" * (MOD(TRUNC("a"), TRUNC("b")))`, `"c" * (TRUNC("a")::bigint % TRUNC("b")::bigint)`},
+		{"2^-x", `POWER(2.0, (-"x"))`, `2.0 ^ (-"x")`},
+		{"(a < b) + 1", `CASE WHEN "a" < "b" THEN 1.0 ELSE 0.0 END + 1.0`,
+			`CASE WHEN "a" < "b" THEN 1.0 ELSE 0.0 END + 1.0`},
+		{"if(x != 0, sqrt(x), -1)", `CASE WHEN "x" <> 0.0 THEN SQRT("x") ELSE -1.0 END`,
+			`CASE WHEN "x" <> 0.0 THEN SQRT("x") ELSE -1.0 END`},
+		{"piecewise(x, 1, y == 2, abs(y))", `CASE WHEN "x" <> 0 THEN 1.0 WHEN "y" = 2.0 THEN ABS("y") END`,
+			`CASE WHEN "x" <> 0 THEN 1.0 WHEN "y" = 2.0 THEN ABS("y") END`},
+		{"coalesce(a, b, 0) / 2", `COALESCE("a", "b", 0.0) / 2.0`, `COALESCE("a", "b", 0.0) / 2.0`},
+		{"let m = a - b in m * m", `("a" - "b") * ("a" - "b")`, `("a" - "b") * ("a" - "b")`},
+		{"order.total * 1.5e-3", `"order.total" * 0.0015`, `"order.total" * 0.0015`},
+	}
+	for _, d := range data {
+		exp, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if res, err := sqlcode.Write(exp, sqlcode.Options{}); err != nil || res != d.standard {
+			t.Error("incorrect SQL of '" + d.input + "': " + res)
+		}
+		if res, err := sqlcode.Write(exp, sqlcode.Options{Dialect: sqlcode.Postgres}); err != nil || res != d.postgres {
+			t.Error("incorrect PostgreSQL of '" + d.input + "': " + res)
+		}
+	}
+
+	exp, _ := p.Parse("foo(price, 2) + qty")
+	opts := sqlcode.Options{
+		Dialect: sqlcode.Dialect{Function: func(name string, args []string) (string, bool) {
+			if name == "foo" {
+				return "my_foo(" + strings.Join(args, ", ") + ")", true
+			}
+			return sqlcode.Function(name, args)
+		}},
+		Column: func(name string) string { return "t." + name },
+	}
+	if res, err := sqlcode.Write(exp, opts); err != nil || res != "my_foo(t.price, 2.0) + t.qty" {
+		t.Error("incorrect SQL with hooks: " + res)
+	}
+
+	for _, input := range []string{"foo(x)", "to(x, \"km\")", "iferror(1 / x, 0)", "a = 1; a", "sqrt(x, y)", "inf * x"} {
+		exp, err := p.Parse(input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if _, err := sqlcode.Write(exp, sqlcode.Options{}); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+}
+
+// TestSQLite - the SQL of the standard dialect returns the same results as the evaluator
+func TestSQLite(t *testing.T) {
+	sqlite, err := exec.LookPath("sqlite3")
+	if err != nil {
+		t.Skip("sqlite3 isn't found")
+	}
+	corpus := []string{
+		"price * qty * (1 - 15 / 100) + 2 ^ 3", "a - (b - c) * -a", "a / b % 3", "-(a + b) ^ 2", "- -a",
+		"let m = a - b in if(m > 0, sqrt(m), coalesce(c, b))", "piecewise(a < 0, -a, a == 0, 0, 1)",
+		"if(a >= b, a != c, a <= c) + (a > b)", "abs(b - a) / 4", "price % qty * 1.5 % 2", "-price / 2.5 % qty",
+	}
+	vars := map[string]float64{"price": 10, "qty": 3, "a": 4, "b": 2, "c": 5}
+
+	p := parser.NewParser()
+	var values []string
+	for name, val := range vars {
+		values = append(values, strconv.FormatFloat(val, 'g', -1, 64)+" AS "+sqlcode.QuoteIdentifier(name))
+	}
+	var selects []string
+	var need []float64
+	for _, input := range corpus {
+		exp, err := p.Parse(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := exp.Evaluate(vars, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		need = append(need, res)
+		sql, err := sqlcode.Write(exp, sqlcode.Options{})
+		if err != nil {
+			t.Fatal("can't write '" + input + "': " + err.Error())
+		}
+		selects = append(selects, "SELECT "+sql+" FROM (SELECT "+strings.Join(values, ", ")+");")
+	}
+
+	out, err := exec.Command(sqlite, ":memory:", strings.Join(selects, "\n")).CombinedOutput()
+	if err != nil {
+		t.Fatal("SQL fails: " + err.Error() + "\n" + string(out))
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != len(need) {
+		t.Fatal("incorrect count of results: " + strconv.Itoa(len(lines)))
+	}
+	for i, line := range lines {
+		res, err := strconv.ParseFloat(line, 64)
+		if err != nil || math.Abs(res-need[i]) > 1e-9*math.Max(1, math.Abs(need[i])) {
+			t.Error("incorrect result of '" + corpus[i] + "': " + line + ", need: " +
+				strconv.FormatFloat(need[i], 'g', -1, 64))
+		}
+	}
+}
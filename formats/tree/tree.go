@@ -0,0 +1,165 @@
+package tree
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// Exporter - draws the expression tree as Graphviz DOT or as indented text.
+// If the parser is set, the nodes are evaluated with the variables and their values are printed
+type Exporter struct {
+	Parser interfaces.ExpParser
+	Vars   map[string]float64
+}
+
+// item - the node of the drawn tree
+type item struct {
+	label    string
+	leaf     bool
+	children []*item
+}
+
+// ASCII - indented tree, one node per line:
+//
+//	binary + = 5
+//	├── number 2 = 2
+//	└── call sqrt = 3
+//	    └── variable x = 9
+func (e *Exporter) ASCII(exp interfaces.Expression) string {
+	var sb strings.Builder
+	root := e.build(exp, e.Vars)
+	sb.WriteString(root.label + "\n")
+	writeChildren(&sb, root, "")
+	return sb.String()
+}
+
+func writeChildren(sb *strings.Builder, it *item, indent string) {
+	for i, child := range it.children {
+		branch, next := "├── ", "│   "
+		if i == len(it.children)-1 {
+			branch, next = "└── ", "    "
+		}
+		sb.WriteString(indent + branch + child.label + "\n")
+		writeChildren(sb, child, indent+next)
+	}
+}
+
+// DOT - the graph for Graphviz: dot -Tsvg tree.dot > tree.svg
+func (e *Exporter) DOT(exp interfaces.Expression) string {
+	var sb strings.Builder
+	sb.WriteString("digraph expression {\n")
+	sb.WriteString("\tnode [shape=box];\n")
+	id := 0
+	var write func(it *item) int
+	write = func(it *item) int {
+		n := id
+		id++
+		name := "n" + strconv.Itoa(n)
+		sb.WriteString("\t" + name + " [label=" + quote(it.label))
+		if it.leaf {
+			sb.WriteString(", shape=ellipse")
+		}
+		sb.WriteString("];\n")
+		for _, child := range it.children {
+			c := write(child)
+			sb.WriteString("\t" + name + " -> n" + strconv.Itoa(c) + ";\n")
+		}
+		return n
+	}
+	write(e.build(exp, e.Vars))
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// quote - string of DOT in double quotes
+func quote(str string) string {
+	str = strings.ReplaceAll(str, "\\", "\\\\")
+	str = strings.ReplaceAll(str, "\"", "\\\"")
+	return "\"" + str + "\""
+}
+
+// build - the nodes with labels and values. Let-bindings and assignments extend the variables of the next nodes
+func (e *Exporter) build(exp interfaces.Expression, vars map[string]float64) *item {
+	it := &item{}
+	switch n := exp.(type) {
+	case *internal.Term:
+		it.leaf = true
+		switch _, err := strconv.ParseFloat(n.Val, 64); {
+		case n.Val == "":
+			it.label = "empty"
+		case err == nil:
+			it.label = "number " + n.Val
+		case internal.IsQuoted(n.Val):
+			it.label = "string " + n.Val
+		default:
+			it.label = "variable " + n.Val
+		}
+
+	case *internal.Node:
+		it.label = "binary " + n.Op
+		it.children = []*item{e.build(n.LExp, vars), e.build(n.RExp, vars)}
+
+	case *internal.Unary:
+		it.label = "unary " + n.Op
+		it.children = []*item{e.build(n.Exp, vars)}
+
+	case interfaces.Function:
+		it.label = "call " + n.GetOperation()
+		for _, arg := range n.GetArgs() {
+			it.children = append(it.children, e.build(arg, vars))
+		}
+
+	case *internal.Let:
+		it.label = "let " + n.Name
+		scope := copyVars(vars)
+		if e.Parser != nil {
+			if val, err := n.Value.Evaluate(copyVars(vars), e.Parser); err == nil {
+				scope[n.Name] = val
+			}
+		}
+		it.children = []*item{e.build(n.Value, vars), e.build(n.Body, scope)}
+
+	case *internal.Assign:
+		it.label = "assign " + n.Name
+		it.children = []*item{e.build(n.Exp, vars)}
+
+	case *internal.Script:
+		it.label = "script"
+		env := copyVars(vars)
+		for _, stmt := range n.Stmts {
+			it.children = append(it.children, e.build(stmt, env))
+			if e.Parser != nil {
+				// assignments store the values to env for the next statements
+				stmt.Evaluate(env, e.Parser)
+			}
+		}
+
+	default:
+		it.label = exp.String()
+	}
+
+	// strings are arguments of functions, they haven't numeric values
+	if t, ok := exp.(*internal.Term); e.Parser != nil && !(ok && internal.IsQuoted(t.Val)) {
+		it.label += value(exp.Evaluate(copyVars(vars), e.Parser))
+	}
+	return it
+}
+
+// copyVars - the variables, which aren't modified by the assignments of the evaluated node
+func copyVars(vars map[string]float64) map[string]float64 {
+	res := make(map[string]float64, len(vars))
+	for name, val := range vars {
+		res[name] = val
+	}
+	return res
+}
+
+func value(val float64, err error) string {
+	if err != nil {
+		return " = error: " + err.Error()
+	}
+	return " = " + strconv.FormatFloat(val, 'g', -1, 64)
+}
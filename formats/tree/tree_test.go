@@ -0,0 +1,100 @@
+package tree_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/formats/tree"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+func TestASCII(t *testing.T) {
+	type TestData struct {
+		input  string
+		output string
+	}
+	data := []TestData{
+		{"2 + sqrt(x)", `binary +
+├── number 2
+└── call sqrt
+    └── variable x
+`},
+		{"a = -b; let m = a * 2 in to(m, \"km\")", `script
+├── assign a
+│   └── unary -
+│       └── variable b
+└── let m
+    ├── binary *
+    │   ├── variable a
+    │   └── number 2
+    └── call to
+        ├── variable m
+        └── string "km"
+`},
+	}
+	p := parser.NewParser()
+	for _, d := range data {
+		exp, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		e := tree.Exporter{}
+		if res := e.ASCII(exp); res != d.output {
+			t.Error("incorrect tree of '" + d.input + "':\n" + res)
+		}
+	}
+
+	exp, _ := p.Parse("a = 2; let m = a - 1 in m * sqrt(b) + y")
+	e := tree.Exporter{Parser: p, Vars: map[string]float64{"b": 16}}
+	need := `script = error: value 'y not found in map
+├── assign a = 2
+│   └── number 2 = 2
+└── let m = error: value 'y not found in map
+    ├── binary - = 1
+    │   ├── variable a = 2
+    │   └── number 1 = 1
+    └── binary + = error: value 'y not found in map
+        ├── binary * = 4
+        │   ├── variable m = 1
+        │   └── call sqrt = 4
+        │       └── variable b = 16
+        └── variable y = error: value 'y not found in map
+`
+	if res := e.ASCII(exp); res != need {
+		t.Error("incorrect tree with values:\n" + res)
+	}
+}
+
+func TestDOT(t *testing.T) {
+	p := parser.NewParser()
+	exp, err := p.Parse("-x * to(y, \"km\")")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := tree.Exporter{}
+	need := `digraph expression {
+	node [shape=box];
+	n0 [label="unary -"];
+	n1 [label="binary *"];
+	n2 [label="variable x", shape=ellipse];
+	n1 -> n2;
+	n3 [label="call to"];
+	n4 [label="variable y", shape=ellipse];
+	n3 -> n4;
+	n5 [label="string \"km\"", shape=ellipse];
+	n3 -> n5;
+	n1 -> n3;
+	n0 -> n1;
+}
+`
+	if res := e.DOT(exp); res != need {
+		t.Error("incorrect graph:\n" + res)
+	}
+
+	res := p.TreeDOT(exp, map[string]float64{"x": 2, "y": 3})
+	if !strings.Contains(res, `n0 [label="unary - = error: string \"km\" can't`) ||
+		!strings.Contains(res, `n2 [label="variable x = 2", shape=ellipse];`) {
+		t.Error("incorrect graph with values:\n" + res)
+	}
+}
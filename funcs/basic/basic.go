@@ -20,6 +20,7 @@ var (
 			"-":    UnarySub,
 			"sqrt": Sqrt,
 			"abs":  Abs,
+			"to":   To,
 		},
 		{
 			"*": Mult,
@@ -32,6 +33,34 @@ var (
 			"-": Sub,
 		},
 	}
+
+	// the comparison operators, which have lower priority than the operators
+	DefaultComparisons = map[string]funcs.FuncType{
+		"<":  Less,
+		"<=": LessOrEqual,
+		">":  Greater,
+		">=": GreaterOrEqual,
+		"==": Equal,
+		"!=": NotEqual,
+	}
+
+	// the special forms, which evaluate only needed arguments
+	DefaultSpecialForms = map[string]funcs.SpecialFormType{
+		"if":        If,
+		"piecewise": Piecewise,
+		"case":      Piecewise,
+		"coalesce":  Coalesce,
+		"iferror":   IfError,
+	}
+
+	// count of arguments of the functions with fixed arity, other functions are variadic
+	DefaultArities = map[string]int{
+		"sqrt":    1,
+		"abs":     1,
+		"to":      2,
+		"if":      3,
+		"iferror": 2,
+	}
 )
 
 func UnarySum(args ...float64) (float64, error) {
@@ -64,6 +93,14 @@ func Abs(args ...float64) (float64, error) {
 	return math.Abs(args[0]), nil
 }
 
+// To - conversion to the unit, for the dimensionless numbers it returns the first argument
+func To(args ...float64) (float64, error) {
+	if len(args) != 2 {
+		return 0, errors.New("incorrect count of args for 'to' function. Need: 2, but get: " + strconv.Itoa(len(args)))
+	}
+	return args[0], nil
+}
+
 func Mult(args ...float64) (float64, error) {
 	if len(args) != 2 {
 		return 0, errors.New("incorrect count of args for multiplication operator. Need: 2, but get: " + strconv.Itoa(len(args)))
@@ -93,7 +130,8 @@ func DivReminder(args ...float64) (float64, error) {
 	if len(args) != 2 {
 		return 0, errors.New("incorrect count of args for % operator. Need: 2, but get: " + strconv.Itoa(len(args)))
 	}
-	if args[1] == 0.0 {
+	// the integer part of the divisor is used, so 0.5 is the zero divisor
+	if int(args[1]) == 0 {
 		return 0, errors.New("incorrect divisor for % operator")
 	}
 	return float64(int(args[0]) % int(args[1])), nil
@@ -112,3 +150,108 @@ func Sub(args ...float64) (float64, error) {
 	}
 	return args[0] - args[1], nil
 }
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func compare(name string, args []float64, cmp func(a, b float64) bool) (float64, error) {
+	if len(args) != 2 {
+		return 0, errors.New("incorrect count of args for " + name + " operator. Need: 2, but get: " + strconv.Itoa(len(args)))
+	}
+	return boolToFloat(cmp(args[0], args[1])), nil
+}
+
+func Less(args ...float64) (float64, error) {
+	return compare("<", args, func(a, b float64) bool { return a < b })
+}
+
+func LessOrEqual(args ...float64) (float64, error) {
+	return compare("<=", args, func(a, b float64) bool { return a <= b })
+}
+
+func Greater(args ...float64) (float64, error) {
+	return compare(">", args, func(a, b float64) bool { return a > b })
+}
+
+func GreaterOrEqual(args ...float64) (float64, error) {
+	return compare(">=", args, func(a, b float64) bool { return a >= b })
+}
+
+func Equal(args ...float64) (float64, error) {
+	return compare("==", args, func(a, b float64) bool { return a == b })
+}
+
+func NotEqual(args ...float64) (float64, error) {
+	return compare("!=", args, func(a, b float64) bool { return a != b })
+}
+
+// If - if(condition, then, else), non-zero condition is true
+func If(args ...funcs.Thunk) (float64, error) {
+	if len(args) != 3 {
+		return 0, errors.New("incorrect count of args for 'if' function. Need: 3, but get: " + strconv.Itoa(len(args)))
+	}
+	cond, err := args[0]()
+	if err != nil {
+		return 0, err
+	}
+	if cond != 0 {
+		return args[1]()
+	}
+	return args[2]()
+}
+
+// Piecewise - piecewise(cond1, val1, cond2, val2, ..., default), the value of the first true condition.
+// The default value is optional
+func Piecewise(args ...funcs.Thunk) (float64, error) {
+	if len(args) < 2 {
+		return 0, errors.New("incorrect count of args for 'piecewise' function. Need: 2 or more, but get: " + strconv.Itoa(len(args)))
+	}
+	for i := 0; i+1 < len(args); i += 2 {
+		cond, err := args[i]()
+		if err != nil {
+			return 0, err
+		}
+		if cond != 0 {
+			return args[i+1]()
+		}
+	}
+	if len(args)%2 == 1 {
+		return args[len(args)-1]()
+	}
+	return 0, errors.New("no condition of 'piecewise' function is satisfied")
+}
+
+// Coalesce - the first argument, which is evaluated without error and isn't NaN
+func Coalesce(args ...funcs.Thunk) (float64, error) {
+	if len(args) < 1 {
+		return 0, errors.New("incorrect count of args for 'coalesce' function. Need: 1 or more, but get: 0")
+	}
+	var err error
+	for _, arg := range args {
+		var res float64
+		res, err = arg()
+		if err == nil && !math.IsNaN(res) {
+			return res, nil
+		}
+	}
+	if err == nil {
+		err = errors.New("all args of 'coalesce' function are NaN")
+	}
+	return 0, err
+}
+
+// IfError - iferror(expr, fallback), the fallback is evaluated only if the expression fails
+func IfError(args ...funcs.Thunk) (float64, error) {
+	if len(args) != 2 {
+		return 0, errors.New("incorrect count of args for 'iferror' function. Need: 2, but get: " + strconv.Itoa(len(args)))
+	}
+	res, err := args[0]()
+	if err != nil {
+		return args[1]()
+	}
+	return res, nil
+}
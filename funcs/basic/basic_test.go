@@ -1,6 +1,7 @@
 package basic_test
 
 import (
+	"errors"
 	"math"
 	"strconv"
 	"testing"
@@ -230,6 +231,11 @@ func TestDefaultOperators(t *testing.T) {
 		t.Error("incorrect DivReminder error handling")
 	}
 
+	res, err = dfuncs.DivReminder(20, 0.5)
+	if err == nil {
+		t.Error("incorrect DivReminder error handling of fractional divisor")
+	}
+
 	res, err = dfuncs.DivReminder()
 	if res != 0 || err == nil {
 		t.Error("incorrect DivReminder error handling")
@@ -312,4 +318,118 @@ func TestDefaultOperators(t *testing.T) {
 	if res != 0 || err == nil {
 		t.Error("incorrect Sub error handling")
 	}
+
+	// To
+	res, err = dfuncs.To(7, 1)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if res != 7.0 {
+		t.Error("incorrect To result: " + strconv.FormatFloat(res, 'e', 4, 64))
+	}
+
+	res, err = dfuncs.To(1)
+	if res != 0 || err == nil {
+		t.Error("incorrect To error handling")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	type TestData struct {
+		f      func(args ...float64) (float64, error)
+		x, y   float64
+		output float64
+	}
+
+	data := []TestData{
+		{dfuncs.Less, 1, 2, 1}, {dfuncs.Less, 2, 2, 0},
+		{dfuncs.LessOrEqual, 2, 2, 1}, {dfuncs.LessOrEqual, 3, 2, 0},
+		{dfuncs.Greater, 3, 2, 1}, {dfuncs.Greater, 2, 2, 0},
+		{dfuncs.GreaterOrEqual, 2, 2, 1}, {dfuncs.GreaterOrEqual, 1, 2, 0},
+		{dfuncs.Equal, 2, 2, 1}, {dfuncs.Equal, 1, 2, 0},
+		{dfuncs.NotEqual, 1, 2, 1}, {dfuncs.NotEqual, 2, 2, 0},
+	}
+
+	for _, d := range data {
+		res, err := d.f(d.x, d.y)
+		if err != nil {
+			t.Error(err)
+		}
+		if res != d.output {
+			t.Error("incorrect comparison result: " + strconv.FormatFloat(res, 'e', 4, 64))
+		}
+	}
+
+	if _, err := dfuncs.Less(1); err == nil {
+		t.Error("incorrect Less error handling")
+	}
+}
+
+func TestSpecialForms(t *testing.T) {
+	calls := 0
+	value := func(v float64) func() (float64, error) {
+		return func() (float64, error) {
+			calls++
+			return v, nil
+		}
+	}
+	fail := func() (float64, error) {
+		calls++
+		return 0, errors.New("fail")
+	}
+
+	// If
+	res, err := dfuncs.If(value(1), value(2), fail)
+	if err != nil || res != 2 || calls != 2 {
+		t.Error("incorrect If result: " + strconv.FormatFloat(res, 'e', 4, 64))
+	}
+
+	calls = 0
+	res, err = dfuncs.If(value(0), fail, value(3))
+	if err != nil || res != 3 || calls != 2 {
+		t.Error("incorrect If result: " + strconv.FormatFloat(res, 'e', 4, 64))
+	}
+
+	if _, err = dfuncs.If(value(1), value(2)); err == nil {
+		t.Error("incorrect If error handling")
+	}
+
+	// Piecewise
+	calls = 0
+	res, err = dfuncs.Piecewise(value(0), fail, value(1), value(5), fail, fail)
+	if err != nil || res != 5 || calls != 3 {
+		t.Error("incorrect Piecewise result: " + strconv.FormatFloat(res, 'e', 4, 64))
+	}
+
+	res, err = dfuncs.Piecewise(value(0), value(1), value(7))
+	if err != nil || res != 7 {
+		t.Error("incorrect Piecewise default: " + strconv.FormatFloat(res, 'e', 4, 64))
+	}
+
+	if _, err = dfuncs.Piecewise(value(0), value(1)); err == nil {
+		t.Error("incorrect Piecewise error handling")
+	}
+
+	// Coalesce
+	res, err = dfuncs.Coalesce(fail, value(math.NaN()), value(4), fail)
+	if err != nil || res != 4 {
+		t.Error("incorrect Coalesce result: " + strconv.FormatFloat(res, 'e', 4, 64))
+	}
+
+	if _, err = dfuncs.Coalesce(fail, value(math.NaN())); err == nil {
+		t.Error("incorrect Coalesce error handling")
+	}
+
+	// IfError
+	res, err = dfuncs.IfError(fail, value(6))
+	if err != nil || res != 6 {
+		t.Error("incorrect IfError result: " + strconv.FormatFloat(res, 'e', 4, 64))
+	}
+
+	calls = 0
+	res, err = dfuncs.IfError(value(8), fail)
+	if err != nil || res != 8 || calls != 1 {
+		t.Error("incorrect IfError result: " + strconv.FormatFloat(res, 'e', 4, 64))
+	}
 }
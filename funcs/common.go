@@ -3,5 +3,14 @@ package funcs
 // FuncType - internal type of functions
 type FuncType func(args ...float64) (float64, error)
 
-// count of operator priorities
+// Thunk - unevaluated argument of a special form
+type Thunk func() (float64, error)
+
+// SpecialFormType - function, which receives unevaluated arguments and evaluates only needed ones
+type SpecialFormType func(args ...Thunk) (float64, error)
+
+// count of operator priorities of ExpParser.GetFunctions()
 const LevelsOfPriorities = 3
+
+// count of operator priorities with the comparisons, which are the lowest level
+const LevelsOfOperators = LevelsOfPriorities + 1
@@ -0,0 +1,149 @@
+package userfunc
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/overseven/go-math-expression-parser/funcs"
+	"github.com/overseven/go-math-expression-parser/interfaces"
+)
+
+// Definition - the function defined in the expression syntax: f(x, y) = x^2 + y
+type Definition struct {
+	Name   string
+	Params []string
+	Body   interfaces.Expression
+	// Source - text of the body
+	Source string
+}
+
+func (d *Definition) checkArgs(count int) error {
+	if count != len(d.Params) {
+		return errors.New("incorrect count of args for '" + d.Name + "' function. Need: " +
+			strconv.Itoa(len(d.Params)) + ", but get: " + strconv.Itoa(count))
+	}
+	return nil
+}
+
+// checkDepth - depth is the count of the calls of the definitions, which are evaluated now by this call chain
+func (d *Definition) checkDepth(depth, maxDepth int) error {
+	if depth >= maxDepth {
+		return errors.New("recursion depth limit " + strconv.Itoa(maxDepth) + " is exceeded in '" + d.Name + "' function")
+	}
+	return nil
+}
+
+// Evaluate - execute the body with the parameters bound to the args. The calls of defs in the body know the depth
+// of recursion, so the definition can be evaluated concurrently
+func (d *Definition) Evaluate(p interfaces.ExpParser, defs map[string]*Definition, maxDepth int, args ...float64) (float64, error) {
+	return d.evaluate(&bodyParser{ExpParser: p, defs: defs, maxDepth: maxDepth}, args)
+}
+
+func (d *Definition) evaluate(p *bodyParser, args []float64) (float64, error) {
+	if err := d.checkDepth(p.depth, p.maxDepth); err != nil {
+		return 0, err
+	}
+	if err := d.checkArgs(len(args)); err != nil {
+		return 0, err
+	}
+	vars := make(map[string]float64, len(args))
+	for i, name := range d.Params {
+		vars[name] = args[i]
+	}
+	return d.Body.Evaluate(vars, &bodyParser{ExpParser: p.ExpParser, defs: p.defs, depth: p.depth + 1, maxDepth: p.maxDepth})
+}
+
+// EvaluateIn - execute the body over the values of the domain with the parameters bound to the args
+func (d *Definition) EvaluateIn(dom interfaces.Domain, args ...interfaces.Value) (interfaces.Value, error) {
+	if err := d.checkArgs(len(args)); err != nil {
+		return nil, err
+	}
+	vars := make(map[string]interfaces.Value, len(args))
+	for i, name := range d.Params {
+		vars[name] = args[i]
+	}
+	return d.Body.EvaluateIn(vars, dom)
+}
+
+// toString conversation, the result can be parsed back
+func (d *Definition) String() string {
+	return d.Name + "(" + strings.Join(d.Params, ", ") + ") = " + d.Source
+}
+
+// bodyParser - the parser of the body of the defined function, it passes the depth of recursion to the calls
+// of the definitions
+type bodyParser struct {
+	interfaces.ExpParser
+	defs     map[string]*Definition
+	depth    int
+	maxDepth int
+}
+
+// Call - evaluate the defined function or call the function of the parser
+func (p *bodyParser) Call(name string, args ...float64) (float64, error) {
+	if def, ok := p.defs[name]; ok {
+		return def.evaluate(p, args)
+	}
+	return p.GetFunctions()[0][name](args...)
+}
+
+// GetComparisons - the comparisons of the parser
+func (p *bodyParser) GetComparisons() map[string]funcs.FuncType {
+	if c, ok := p.ExpParser.(interfaces.Comparer); ok {
+		return c.GetComparisons()
+	}
+	return nil
+}
+
+// GetSpecialForms - the special forms of the parser
+func (p *bodyParser) GetSpecialForms() map[string]funcs.SpecialFormType {
+	if s, ok := p.ExpParser.(interfaces.SpecialFormer); ok {
+		return s.GetSpecialForms()
+	}
+	return nil
+}
+
+// definitionsDomain - evaluates the defined functions over the values of the wrapped domain
+type definitionsDomain struct {
+	interfaces.Domain
+	defs map[string]*Definition
+	// depth of recursion of the body, which is evaluated in this domain
+	depth    int
+	maxDepth int
+}
+
+// WithDefinitions - wrap the domain to evaluate bodies of the defined functions instead of calling them
+func WithDefinitions(d interfaces.Domain, defs map[string]*Definition, maxDepth int) interfaces.Domain {
+	if len(defs) == 0 {
+		return d
+	}
+	return &definitionsDomain{Domain: d, defs: defs, maxDepth: maxDepth}
+}
+
+// Call - evaluate the defined function or call the function of the wrapped domain
+func (d *definitionsDomain) Call(name string, args ...interfaces.Value) (interfaces.Value, error) {
+	def, ok := d.defs[name]
+	if !ok {
+		return d.Domain.Call(name, args...)
+	}
+	if err := def.checkDepth(d.depth, d.maxDepth); err != nil {
+		return nil, err
+	}
+	return def.EvaluateIn(&definitionsDomain{Domain: d.Domain, defs: d.defs, depth: d.depth + 1, maxDepth: d.maxDepth}, args...)
+}
+
+// Form - the special form of the wrapped domain
+func (d *definitionsDomain) Form(name string) (bool, bool) {
+	return lookupForm(d.Domain, name)
+}
+
+// Truth - the truth of the wrapped domain
+func (d *definitionsDomain) Truth(cond interfaces.Value) (bool, bool, error) {
+	return truth(d.Domain, cond)
+}
+
+// Join - the join of the wrapped domain
+func (d *definitionsDomain) Join(x, y interfaces.Value) (interfaces.Value, error) {
+	return join(d.Domain, x, y)
+}
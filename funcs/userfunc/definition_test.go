@@ -0,0 +1,70 @@
+package userfunc_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/domains/interval"
+	"github.com/overseven/go-math-expression-parser/funcs/userfunc"
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+func TestDefinitionEvaluate(t *testing.T) {
+	p := parser.NewParser()
+	// f(x, y) = x * y + 1
+	def := userfunc.Definition{
+		Name:   "f",
+		Params: []string{"x", "y"},
+		Body: &internal.Node{Op: "+",
+			LExp: &internal.Node{Op: "*", LExp: &internal.Term{Val: "x"}, RExp: &internal.Term{Val: "y"}},
+			RExp: &internal.Term{Val: "1"}},
+		Source: "x * y + 1",
+	}
+
+	res, err := def.Evaluate(p, nil, 10, 3, 4)
+	if err != nil {
+		t.Error(err)
+	}
+	if !almostEqual(res, 13) {
+		t.Error("incorrect result = " + strconv.FormatFloat(res, 'e', 4, 64))
+	}
+
+	if _, err := def.Evaluate(p, nil, 10, 3); err == nil {
+		t.Error("incorrect args count error handling")
+	}
+	if _, err := def.Evaluate(p, nil, 0, 3, 4); err == nil {
+		t.Error("incorrect depth limit error handling")
+	}
+
+	if def.String() != "f(x, y) = x * y + 1" {
+		t.Error("incorrect string conversion = " + def.String())
+	}
+}
+
+func TestWithDefinitions(t *testing.T) {
+	p := parser.NewParser()
+	// sq(x) = x * x
+	def := &userfunc.Definition{
+		Name:   "sq",
+		Params: []string{"x"},
+		Body:   &internal.Node{Op: "*", LExp: &internal.Term{Val: "x"}, RExp: &internal.Term{Val: "x"}},
+	}
+	d := userfunc.WithDefinitions(interval.NewDomain(p.Levels()), map[string]*userfunc.Definition{"sq": def}, 10)
+
+	call := userfunc.Func{Op: "sq", Args: []interfaces.Expression{&internal.Term{Val: "a"}}}
+	res, err := call.EvaluateIn(map[string]interfaces.Value{"a": interval.New(2, 3)}, d)
+	if err != nil {
+		t.Error(err)
+	}
+	if x := res.(interval.Interval); !x.Contains(4) || !x.Contains(9) || x.Width() > 5.1 {
+		t.Error("incorrect result = " + x.String())
+	}
+
+	// other functions are called by the wrapped domain
+	call = userfunc.Func{Op: "sqrt", Args: []interfaces.Expression{&internal.Term{Val: "a"}}}
+	if _, err := call.EvaluateIn(map[string]interfaces.Value{"a": interval.New(4, 9)}, d); err != nil {
+		t.Error(err)
+	}
+}
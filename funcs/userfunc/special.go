@@ -0,0 +1,164 @@
+package userfunc
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/overseven/go-math-expression-parser/interfaces"
+)
+
+// specialFormIn - built-in special form, which is evaluated over the values of the domain
+type specialFormIn func(args []interfaces.Expression, vars map[string]interfaces.Value,
+	d interfaces.Domain, c interfaces.Conditional) (interfaces.Value, error)
+
+// the built-in special forms of funcs/basic for the evaluation over the values of domains
+var specialForms = map[string]specialFormIn{
+	"if":        ifIn,
+	"piecewise": piecewiseIn,
+	"case":      piecewiseIn,
+	"coalesce":  coalesceIn,
+	"iferror":   ifErrorIn,
+}
+
+// lookupForm - the special form of the domain, the built-in forms are used if the domain doesn't know the forms
+func lookupForm(d interfaces.Domain, name string) (isForm bool, builtin bool) {
+	if forms, ok := d.(interfaces.Forms); ok {
+		return forms.Form(name)
+	}
+	_, ok := specialForms[name]
+	return ok, ok
+}
+
+// formsDomain - the domain with the special forms of the parser
+type formsDomain struct {
+	interfaces.Domain
+	builtin map[string]bool
+}
+
+// WithSpecialForms - wrap the domain to evaluate the special forms of the parser. builtin contains all special forms,
+// the value is true for the default ones, other forms aren't supported over the values of the domain
+func WithSpecialForms(d interfaces.Domain, builtin map[string]bool) interfaces.Domain {
+	return &formsDomain{Domain: d, builtin: builtin}
+}
+
+// Form - the special form of the parser
+func (d *formsDomain) Form(name string) (bool, bool) {
+	builtin, ok := d.builtin[name]
+	return ok, builtin
+}
+
+// Truth - the truth of the wrapped domain
+func (d *formsDomain) Truth(cond interfaces.Value) (bool, bool, error) {
+	return truth(d.Domain, cond)
+}
+
+// Join - the join of the wrapped domain
+func (d *formsDomain) Join(x, y interfaces.Value) (interfaces.Value, error) {
+	return join(d.Domain, x, y)
+}
+
+func truth(d interfaces.Domain, cond interfaces.Value) (bool, bool, error) {
+	c, ok := d.(interfaces.Conditional)
+	if !ok {
+		return false, false, errors.New("conditions are not supported in this evaluation mode")
+	}
+	return c.Truth(cond)
+}
+
+func join(d interfaces.Domain, x, y interfaces.Value) (interfaces.Value, error) {
+	c, ok := d.(interfaces.Conditional)
+	if !ok {
+		return nil, errors.New("conditions are not supported in this evaluation mode")
+	}
+	return c.Join(x, y)
+}
+
+// branch - evaluate one of values, if the truth of the condition is known, or join both values
+func branch(cond interfaces.Expression, vars map[string]interfaces.Value, d interfaces.Domain, c interfaces.Conditional,
+	then, otherwise func() (interfaces.Value, error)) (interfaces.Value, error) {
+	val, err := cond.EvaluateIn(vars, d)
+	if err != nil {
+		return nil, err
+	}
+	truth, known, err := c.Truth(val)
+	if err != nil {
+		return nil, err
+	}
+	if known && truth {
+		return then()
+	}
+	if known {
+		return otherwise()
+	}
+	x, err := then()
+	if err != nil {
+		return nil, err
+	}
+	y, err := otherwise()
+	if err != nil {
+		return nil, err
+	}
+	return c.Join(x, y)
+}
+
+func ifIn(args []interfaces.Expression, vars map[string]interfaces.Value,
+	d interfaces.Domain, c interfaces.Conditional) (interfaces.Value, error) {
+	if len(args) != 3 {
+		return nil, errors.New("incorrect count of args for 'if' function. Need: 3, but get: " + strconv.Itoa(len(args)))
+	}
+	return branch(args[0], vars, d, c, func() (interfaces.Value, error) {
+		return args[1].EvaluateIn(vars, d)
+	}, func() (interfaces.Value, error) {
+		return args[2].EvaluateIn(vars, d)
+	})
+}
+
+func piecewiseIn(args []interfaces.Expression, vars map[string]interfaces.Value,
+	d interfaces.Domain, c interfaces.Conditional) (interfaces.Value, error) {
+	if len(args) < 2 {
+		return nil, errors.New("incorrect count of args for 'piecewise' function. Need: 2 or more, but get: " + strconv.Itoa(len(args)))
+	}
+	var pieces func(i int) (interfaces.Value, error)
+	pieces = func(i int) (interfaces.Value, error) {
+		if i == len(args)-1 {
+			return args[i].EvaluateIn(vars, d)
+		}
+		if i == len(args) {
+			return nil, errors.New("no condition of 'piecewise' function is satisfied")
+		}
+		return branch(args[i], vars, d, c, func() (interfaces.Value, error) {
+			return args[i+1].EvaluateIn(vars, d)
+		}, func() (interfaces.Value, error) {
+			return pieces(i + 2)
+		})
+	}
+	return pieces(0)
+}
+
+func coalesceIn(args []interfaces.Expression, vars map[string]interfaces.Value,
+	d interfaces.Domain, c interfaces.Conditional) (interfaces.Value, error) {
+	if len(args) < 1 {
+		return nil, errors.New("incorrect count of args for 'coalesce' function. Need: 1 or more, but get: 0")
+	}
+	var err error
+	for _, arg := range args {
+		var res interfaces.Value
+		res, err = arg.EvaluateIn(vars, d)
+		if err == nil {
+			return res, nil
+		}
+	}
+	return nil, err
+}
+
+func ifErrorIn(args []interfaces.Expression, vars map[string]interfaces.Value,
+	d interfaces.Domain, c interfaces.Conditional) (interfaces.Value, error) {
+	if len(args) != 2 {
+		return nil, errors.New("incorrect count of args for 'iferror' function. Need: 2, but get: " + strconv.Itoa(len(args)))
+	}
+	res, err := args[0].EvaluateIn(vars, d)
+	if err != nil {
+		return args[1].EvaluateIn(vars, d)
+	}
+	return res, nil
+}
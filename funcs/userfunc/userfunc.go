@@ -1,7 +1,12 @@
 package userfunc
 
 import (
+	"errors"
+	"strings"
+
+	"github.com/overseven/go-math-expression-parser/funcs"
 	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
 )
 
 // Func - the struct which contains a function and an argument
@@ -16,8 +21,18 @@ func (f *Func) GetVarList(vars map[string]interface{}) {
 	}
 }
 
-// Evaluate function
+// Evaluate function, the arguments of special forms are evaluated on demand
 func (f *Func) Evaluate(vars map[string]float64, p interfaces.ExpParser) (float64, error) {
+	if form, ok := specialForm(p, f.Op); ok {
+		thunks := make([]funcs.Thunk, len(f.Args))
+		for i := range f.Args {
+			arg := f.Args[i]
+			thunks[i] = func() (float64, error) {
+				return arg.Evaluate(vars, p)
+			}
+		}
+		return form(thunks...)
+	}
 	var args []float64
 	for _, arg := range f.Args {
 		res, err := arg.Evaluate(vars, p)
@@ -26,18 +41,57 @@ func (f *Func) Evaluate(vars map[string]float64, p interfaces.ExpParser) (float6
 		}
 		args = append(args, res)
 	}
-	res, err := p.GetFunctions()[0][f.Op](args...)
+	if c, ok := p.(interfaces.Caller); ok {
+		return c.Call(f.Op, args...)
+	}
+	fn, ok := p.GetFunctions()[0][f.Op]
+	if !ok {
+		return -1, errors.New("function '" + f.Op + "' is not supported")
+	}
+	res, err := fn(args...)
 	return res, err
 }
 
+// specialForm - the special form of the parser, which implements SpecialFormer
+func specialForm(p interfaces.ExpParser, name string) (funcs.SpecialFormType, bool) {
+	if s, ok := p.(interfaces.SpecialFormer); ok {
+		form, ok := s.GetSpecialForms()[name]
+		return form, ok
+	}
+	return nil, false
+}
+
+// EvaluateIn - evaluate function over the values of the domain
+func (f *Func) EvaluateIn(vars map[string]interfaces.Value, d interfaces.Domain) (interfaces.Value, error) {
+	if isForm, builtin := lookupForm(d, f.Op); isForm {
+		form, ok := specialForms[f.Op]
+		if !ok || !builtin {
+			return nil, errors.New("special form '" + f.Op + "' is not supported in domain")
+		}
+		c, ok := d.(interfaces.Conditional)
+		if !ok {
+			return nil, errors.New("special form '" + f.Op + "' is not supported in this evaluation mode")
+		}
+		return form(f.Args, vars, d, c)
+	}
+	var args []interfaces.Value
+	for _, arg := range f.Args {
+		res, err := arg.EvaluateIn(vars, d)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, res)
+	}
+	return d.Call(f.Op, args...)
+}
+
 // toString conversation
 func (f *Func) String() string {
-	str := ""
-	for _, arg := range f.Args {
-		str += arg.String() + ","
+	args := make([]string, len(f.Args))
+	for i, arg := range f.Args {
+		args[i] = arg.String()
 	}
-	str = str[:len(str)-1]
-	return "( " + string(f.Op) + " ( " + str + " ) )"
+	return "( " + f.Op + " ( " + strings.Join(args, ",") + " ) )"
 }
 
 func (f *Func) SetOperation(op string) {
@@ -53,3 +107,22 @@ func (f *Func) SetArgs(args []interfaces.Expression) {
 func (f *Func) GetArgs() []interfaces.Expression {
 	return f.Args
 }
+
+func init() {
+	internal.RegisterKind("call", func() interfaces.Expression { return &Func{} })
+}
+
+// MarshalJSON - {"kind": "call", "name": "max", "args": [...]}
+func (f *Func) MarshalJSON() ([]byte, error) {
+	return internal.EncodeCall(f.Op, f.Args)
+}
+
+// UnmarshalJSON - name and arguments of the function call
+func (f *Func) UnmarshalJSON(data []byte) error {
+	name, args, err := internal.DecodeCall(data)
+	if err != nil {
+		return err
+	}
+	f.Op, f.Args = name, args
+	return nil
+}
@@ -133,6 +133,10 @@ func TestString(t *testing.T) {
 	if f2.String() != "( foo ( ( average ( 2,4,9 ) ),100 ) )" {
 		t.Error("incorrect string conversion = " + f2.String())
 	}
+	f3 := userfunc.Func{"rand", nil}
+	if f3.String() != "( rand (  ) )" {
+		t.Error("incorrect string conversion = " + f3.String())
+	}
 }
 
 func TestSetOperation(t *testing.T) {
@@ -12,10 +12,28 @@ type ExpParser interface {
 	Evaluate(vars map[string]float64) (float64, error)
 }
 
+// Comparer - optional interface of ExpParser with the comparison operators,
+// which have lower priority than the operators of GetFunctions()
+type Comparer interface {
+	GetComparisons() map[string]funcs.FuncType
+}
+
+// SpecialFormer - optional interface of ExpParser with the functions, which receive unevaluated arguments
+type SpecialFormer interface {
+	GetSpecialForms() map[string]funcs.SpecialFormType
+}
+
+// Caller - optional interface of ExpParser, which calls the functions instead of GetFunctions().
+// It's used to evaluate the bodies of the defined functions
+type Caller interface {
+	Call(name string, args ...float64) (float64, error)
+}
+
 // Exp - the base interface for Term and Node structures
 type Expression interface {
 	String() string
 	Evaluate(vars map[string]float64, p ExpParser) (float64, error)
+	EvaluateIn(vars map[string]Value, d Domain) (Value, error)
 	GetVarList(vars map[string]interface{})
 }
 
@@ -27,3 +45,32 @@ type Function interface {
 	SetArgs([]Expression)
 	GetArgs() []Expression
 }
+
+// Value - a value of a Domain (quantity with units, interval, etc.)
+type Value interface{}
+
+// Domain - the set of operations which evaluates an expression over values other than float64
+type Domain interface {
+	// Const - convert a numeric literal to the domain value
+	Const(val float64) (Value, error)
+	// Literal - convert a term which is neither a number nor a defined variable
+	Literal(term string) (Value, error)
+	Unary(op string, x Value) (Value, error)
+	Binary(op string, x, y Value) (Value, error)
+	Call(name string, args ...Value) (Value, error)
+}
+
+// Forms - optional interface of Domain, which knows the special forms of the parser
+type Forms interface {
+	// Form - isForm is true for the special form of the parser, builtin is true if it's the default one,
+	// which is evaluated over the values of the domain
+	Form(name string) (isForm bool, builtin bool)
+}
+
+// Conditional - optional interface of Domain, which is needed to evaluate the built-in special forms
+type Conditional interface {
+	// Truth - truth of the condition, known is false if the value may be both true and false
+	Truth(cond Value) (truth bool, known bool, err error)
+	// Join - the value, which includes both values. It is used for unknown conditions
+	Join(x, y Value) (Value, error)
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"strings"
 
+	"github.com/overseven/go-math-expression-parser/funcs"
 	"github.com/overseven/go-math-expression-parser/interfaces"
 )
 
@@ -19,9 +20,21 @@ func UnaryOperatorExist(op string, p interfaces.ExpParser) (index int, exist boo
 	return -1, false
 }
 
+// Operators - operators of all priorities, the comparisons of the Comparer are the lowest level
+func Operators(p interfaces.ExpParser) [funcs.LevelsOfOperators]map[string]funcs.FuncType {
+	var ops [funcs.LevelsOfOperators]map[string]funcs.FuncType
+	functions := p.GetFunctions()
+	copy(ops[:], functions[:])
+	if c, ok := p.(interfaces.Comparer); ok {
+		ops[funcs.LevelsOfPriorities] = c.GetComparisons()
+	}
+	return ops
+}
+
 func BinaryOperatorExist(op string, p interfaces.ExpParser) (index int, exist bool) {
-	for i := 1; i <= 2; i++ {
-		if _, ok := p.GetFunctions()[i][op]; ok {
+	ops := Operators(p)
+	for i := 1; i < funcs.LevelsOfOperators; i++ {
+		if _, ok := ops[i][op]; ok {
 			return i, true
 		}
 	}
@@ -31,7 +44,14 @@ func BinaryOperatorExist(op string, p interfaces.ExpParser) (index int, exist bo
 // ParenthesisIsCorrect - checks correct parenthesis pairs
 func ParenthesisIsCorrect(str string) (index int, correct bool) {
 	counter := 0
+	quoted := false
 	for i, c := range str {
+		if c == '"' {
+			quoted = !quoted
+		}
+		if quoted {
+			continue
+		}
 		switch c {
 		case '(':
 			counter++
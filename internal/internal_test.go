@@ -6,6 +6,9 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/overseven/go-math-expression-parser/funcs"
+	dfuncs "github.com/overseven/go-math-expression-parser/funcs/basic"
+	"github.com/overseven/go-math-expression-parser/interfaces"
 	"github.com/overseven/go-math-expression-parser/internal"
 	expp "github.com/overseven/go-math-expression-parser/parser"
 )
@@ -105,3 +108,45 @@ func TestBinaryOperatorExist(t *testing.T) {
 		t.Error("operator false found")
 	}
 }
+
+// plainParser - the parser, which implements only ExpParser without the optional interfaces
+type plainParser struct {
+	ops [funcs.LevelsOfPriorities]map[string]funcs.FuncType
+}
+
+func (p *plainParser) AddFunction(f funcs.FuncType, s string) { p.ops[0][s] = f }
+func (p *plainParser) GetFunctions() [funcs.LevelsOfPriorities]map[string]funcs.FuncType {
+	return p.ops
+}
+func (p *plainParser) String() string                                    { return "" }
+func (p *plainParser) Parse(str string) (interfaces.Expression, error)   { return nil, nil }
+func (p *plainParser) Evaluate(vars map[string]float64) (float64, error) { return 0, nil }
+
+func TestPlainParser(t *testing.T) {
+	p := &plainParser{}
+	for i := range p.ops {
+		p.ops[i] = make(map[string]funcs.FuncType)
+		for key, f := range dfuncs.DefaultOperators[i] {
+			p.ops[i][key] = f
+		}
+	}
+	var _ interfaces.ExpParser = p
+	exp, err := expp.NewParser().Parse("sqrt(x) * 2 + if(x, 1, 2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := exp.Evaluate(map[string]float64{"x": 4}, p); err == nil {
+		t.Error("incorrect error handling of the special form")
+	}
+	p.AddFunction(func(args ...float64) (float64, error) { return args[0], nil }, "if")
+	res, err := exp.Evaluate(map[string]float64{"x": 4}, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != 8 {
+		t.Error("incorrect result: " + strconv.FormatFloat(res, 'f', -1, 64))
+	}
+	if _, exist := internal.BinaryOperatorExist("<", p); exist {
+		t.Error("comparison false found")
+	}
+}
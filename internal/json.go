@@ -0,0 +1,350 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/overseven/go-math-expression-parser/interfaces"
+)
+
+// ASTVersion - version of the JSON schema of the expression tree.
+// It's increased on incompatible changes, decoders of older versions are kept for migrations
+const ASTVersion = 1
+
+// kinds of nodes in JSON and constructors of the empty nodes, which decode themselves by UnmarshalJSON
+var nodeKinds = map[string]func() interfaces.Expression{
+	"number":   func() interfaces.Expression { return &Term{} },
+	"variable": func() interfaces.Expression { return &Term{} },
+	"string":   func() interfaces.Expression { return &Term{} },
+	"empty":    func() interfaces.Expression { return &Term{} },
+	"binary":   func() interfaces.Expression { return &Node{} },
+	"unary":    func() interfaces.Expression { return &Unary{} },
+	"let":      func() interfaces.Expression { return &Let{} },
+	"assign":   func() interfaces.Expression { return &Assign{} },
+	"script":   func() interfaces.Expression { return &Script{} },
+}
+
+// RegisterKind - add the kind of node for UnmarshalExpression, the node must implement json.Unmarshaler
+func RegisterKind(kind string, create func() interfaces.Expression) {
+	nodeKinds[kind] = create
+}
+
+// jsonNode - fields of all kinds of nodes, each kind uses its own subset
+type jsonNode struct {
+	Kind       string            `json:"kind"`
+	Op         string            `json:"op,omitempty"`
+	Name       string            `json:"name,omitempty"`
+	Value      json.RawMessage   `json:"value,omitempty"`
+	Left       json.RawMessage   `json:"left,omitempty"`
+	Right      json.RawMessage   `json:"right,omitempty"`
+	Operand    json.RawMessage   `json:"operand,omitempty"`
+	Body       json.RawMessage   `json:"body,omitempty"`
+	Args       []json.RawMessage `json:"args,omitempty"`
+	Statements []json.RawMessage `json:"statements,omitempty"`
+}
+
+// document - the versioned JSON of the expression tree
+type document struct {
+	Version int             `json:"version"`
+	Root    json.RawMessage `json:"root"`
+}
+
+// MarshalDocument - JSON of the expression with the version of the schema: {"version": 1, "root": {...}}
+func MarshalDocument(exp interfaces.Expression) ([]byte, error) {
+	root, err := json.Marshal(exp)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(document{Version: ASTVersion, Root: root})
+}
+
+// UnmarshalDocument - the expression tree of the versioned JSON
+func UnmarshalDocument(data []byte) (interfaces.Expression, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, errors.New("incorrect expression JSON: " + err.Error())
+	}
+	if doc.Version < 1 || doc.Version > ASTVersion {
+		return nil, errors.New("not supported version " + strconv.Itoa(doc.Version) + " of expression JSON, need: 1.." +
+			strconv.Itoa(ASTVersion))
+	}
+	return UnmarshalExpression(doc.Root)
+}
+
+// UnmarshalExpression - the node of the kind, which is set in JSON object
+func UnmarshalExpression(data []byte) (interfaces.Expression, error) {
+	if len(data) == 0 {
+		return nil, errors.New("missing node in expression JSON")
+	}
+	var head struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, errors.New("incorrect expression JSON: " + err.Error())
+	}
+	create, ok := nodeKinds[head.Kind]
+	if !ok {
+		return nil, errors.New("unknown node kind '" + head.Kind + "' in expression JSON")
+	}
+	exp := create()
+	if err := json.Unmarshal(data, exp); err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
+
+// decodeNode - fields of the node, which must be of the kind
+func decodeNode(data []byte, kinds ...string) (*jsonNode, error) {
+	var n jsonNode
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, errors.New("incorrect expression JSON: " + err.Error())
+	}
+	for _, kind := range kinds {
+		if n.Kind == kind {
+			return &n, nil
+		}
+	}
+	return nil, errors.New("unexpected node kind '" + n.Kind + "' in expression JSON")
+}
+
+// decodeList - the nodes of the list
+func decodeList(list []json.RawMessage) ([]interfaces.Expression, error) {
+	exps := make([]interfaces.Expression, len(list))
+	for i, data := range list {
+		exp, err := UnmarshalExpression(data)
+		if err != nil {
+			return nil, err
+		}
+		exps[i] = exp
+	}
+	return exps, nil
+}
+
+// EncodeCall - JSON of the function call: {"kind": "call", "name": "max", "args": [...]}
+func EncodeCall(name string, args []interfaces.Expression) ([]byte, error) {
+	list := make([]json.RawMessage, len(args))
+	for i, arg := range args {
+		data, err := json.Marshal(arg)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = data
+	}
+	// an empty list of arguments is written too
+	return json.Marshal(struct {
+		Kind string            `json:"kind"`
+		Name string            `json:"name"`
+		Args []json.RawMessage `json:"args"`
+	}{"call", name, list})
+}
+
+// DecodeCall - name and arguments of the function call
+func DecodeCall(data []byte) (string, []interfaces.Expression, error) {
+	n, err := decodeNode(data, "call")
+	if err != nil {
+		return "", nil, err
+	}
+	if n.Name == "" {
+		return "", nil, errors.New("function call without name in expression JSON")
+	}
+	args, err := decodeList(n.Args)
+	if err != nil {
+		return "", nil, err
+	}
+	return n.Name, args, nil
+}
+
+// MarshalJSON - {"kind": "number", "value": "2.5"}, {"kind": "variable", "name": "x"} or {"kind": "string", "value": "abc"}
+func (t *Term) MarshalJSON() ([]byte, error) {
+	switch {
+	case t.Val == "":
+		return json.Marshal(jsonNode{Kind: "empty"})
+	case IsQuoted(t.Val):
+		value, _ := json.Marshal(t.Val[1 : len(t.Val)-1])
+		return json.Marshal(jsonNode{Kind: "string", Value: value})
+	}
+	if _, err := strconv.ParseFloat(t.Val, 64); err == nil {
+		value, _ := json.Marshal(t.Val)
+		return json.Marshal(jsonNode{Kind: "number", Value: value})
+	}
+	return json.Marshal(jsonNode{Kind: "variable", Name: t.Val})
+}
+
+// UnmarshalJSON - number, variable, string or empty term
+func (t *Term) UnmarshalJSON(data []byte) error {
+	n, err := decodeNode(data, "number", "variable", "string", "empty")
+	if err != nil {
+		return err
+	}
+	var value string
+	if n.Kind == "number" || n.Kind == "string" {
+		if err := json.Unmarshal(n.Value, &value); err != nil {
+			return errors.New("incorrect value of " + n.Kind + " in expression JSON")
+		}
+	}
+	switch n.Kind {
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return errors.New("incorrect number '" + value + "' in expression JSON")
+		}
+		t.Val = value
+	case "variable":
+		if n.Name == "" {
+			return errors.New("variable without name in expression JSON")
+		}
+		if _, err := strconv.ParseFloat(n.Name, 64); err == nil || IsQuoted(n.Name) {
+			return errors.New("incorrect variable name '" + n.Name + "' in expression JSON")
+		}
+		t.Val = n.Name
+	case "string":
+		t.Val = "\"" + value + "\""
+	default:
+		t.Val = ""
+	}
+	return nil
+}
+
+// MarshalJSON - {"kind": "binary", "op": "+", "left": {...}, "right": {...}}
+func (n *Node) MarshalJSON() ([]byte, error) {
+	left, err := json.Marshal(n.LExp)
+	if err != nil {
+		return nil, err
+	}
+	right, err := json.Marshal(n.RExp)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonNode{Kind: "binary", Op: n.Op, Left: left, Right: right})
+}
+
+// UnmarshalJSON - binary operator and its operands
+func (n *Node) UnmarshalJSON(data []byte) error {
+	node, err := decodeNode(data, "binary")
+	if err != nil {
+		return err
+	}
+	if node.Op == "" {
+		return errors.New("binary operator without op in expression JSON")
+	}
+	if n.LExp, err = UnmarshalExpression(node.Left); err != nil {
+		return err
+	}
+	if n.RExp, err = UnmarshalExpression(node.Right); err != nil {
+		return err
+	}
+	n.Op = node.Op
+	return nil
+}
+
+// MarshalJSON - {"kind": "unary", "op": "-", "operand": {...}}
+func (u *Unary) MarshalJSON() ([]byte, error) {
+	operand, err := json.Marshal(u.Exp)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonNode{Kind: "unary", Op: u.Op, Operand: operand})
+}
+
+// UnmarshalJSON - unary operator and its operand
+func (u *Unary) UnmarshalJSON(data []byte) error {
+	node, err := decodeNode(data, "unary")
+	if err != nil {
+		return err
+	}
+	if node.Op == "" {
+		return errors.New("unary operator without op in expression JSON")
+	}
+	if u.Exp, err = UnmarshalExpression(node.Operand); err != nil {
+		return err
+	}
+	u.Op = node.Op
+	return nil
+}
+
+// MarshalJSON - {"kind": "let", "name": "x", "value": {...}, "body": {...}}
+func (l *Let) MarshalJSON() ([]byte, error) {
+	value, err := json.Marshal(l.Value)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(l.Body)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonNode{Kind: "let", Name: l.Name, Value: value, Body: body})
+}
+
+// UnmarshalJSON - the bound name, its value and the body
+func (l *Let) UnmarshalJSON(data []byte) error {
+	node, err := decodeNode(data, "let")
+	if err != nil {
+		return err
+	}
+	if node.Name == "" {
+		return errors.New("let without name in expression JSON")
+	}
+	if l.Value, err = UnmarshalExpression(node.Value); err != nil {
+		return err
+	}
+	if l.Body, err = UnmarshalExpression(node.Body); err != nil {
+		return err
+	}
+	l.Name = node.Name
+	return nil
+}
+
+// MarshalJSON - {"kind": "assign", "name": "x", "value": {...}}
+func (a *Assign) MarshalJSON() ([]byte, error) {
+	value, err := json.Marshal(a.Exp)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonNode{Kind: "assign", Name: a.Name, Value: value})
+}
+
+// UnmarshalJSON - the variable and its value
+func (a *Assign) UnmarshalJSON(data []byte) error {
+	node, err := decodeNode(data, "assign")
+	if err != nil {
+		return err
+	}
+	if node.Name == "" {
+		return errors.New("assignment without name in expression JSON")
+	}
+	if a.Exp, err = UnmarshalExpression(node.Value); err != nil {
+		return err
+	}
+	a.Name = node.Name
+	return nil
+}
+
+// MarshalJSON - {"kind": "script", "statements": [...]}
+func (s *Script) MarshalJSON() ([]byte, error) {
+	stmts := make([]json.RawMessage, len(s.Stmts))
+	for i, stmt := range s.Stmts {
+		data, err := json.Marshal(stmt)
+		if err != nil {
+			return nil, err
+		}
+		stmts[i] = data
+	}
+	return json.Marshal(jsonNode{Kind: "script", Statements: stmts})
+}
+
+// UnmarshalJSON - statements of the script
+func (s *Script) UnmarshalJSON(data []byte) error {
+	node, err := decodeNode(data, "script")
+	if err != nil {
+		return err
+	}
+	if len(node.Statements) == 0 {
+		return errors.New("script without statements in expression JSON")
+	}
+	stmts, err := decodeList(node.Statements)
+	if err != nil {
+		return err
+	}
+	s.Stmts = stmts
+	return nil
+}
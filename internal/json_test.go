@@ -0,0 +1,48 @@
+package internal_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	_ "github.com/overseven/go-math-expression-parser/funcs/userfunc"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+func TestTermJSON(t *testing.T) {
+	type TestData struct {
+		input  string
+		output string
+	}
+	data := []TestData{
+		{"1.50", `{"kind":"number","value":"1.50"}`},
+		{"x_1", `{"kind":"variable","name":"x_1"}`},
+		{"\"km / h\"", `{"kind":"string","value":"km / h"}`},
+		{"", `{"kind":"empty"}`},
+	}
+	for _, d := range data {
+		res, err := json.Marshal(&internal.Term{Val: d.input})
+		if err != nil || string(res) != d.output {
+			t.Error("incorrect JSON of '" + d.input + "': " + string(res))
+			continue
+		}
+		exp, err := internal.UnmarshalExpression(res)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if term, ok := exp.(*internal.Term); !ok || term.Val != d.input {
+			t.Error("incorrect term of " + d.output + ": " + exp.String())
+		}
+	}
+
+	for _, input := range []string{`{"kind":"number"}`, `{"kind":"variable"}`, `{"kind":"call","args":[]}`, `[]`, `{}`} {
+		if _, err := internal.UnmarshalExpression([]byte(input)); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+
+	var term internal.Term
+	if err := json.Unmarshal([]byte(`{"kind":"binary","op":"+"}`), &term); err == nil {
+		t.Error("incorrect error handling of the node kind")
+	}
+}
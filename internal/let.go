@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"github.com/overseven/go-math-expression-parser/interfaces"
+)
+
+// Let - the struct which binds a value of expression to the name inside the body: let name = value in body
+type Let struct {
+	Name  string
+	Value interfaces.Expression
+	Body  interfaces.Expression
+}
+
+// Evaluate - execute the value once and the body with the bound name, vars are not modified
+func (l *Let) Evaluate(vars map[string]float64, p interfaces.ExpParser) (float64, error) {
+	val, err := l.Value.Evaluate(vars, p)
+	if err != nil {
+		return 0.0, err
+	}
+	scope := make(map[string]float64, len(vars)+1)
+	for name, v := range vars {
+		scope[name] = v
+	}
+	scope[l.Name] = val
+	return l.Body.Evaluate(scope, p)
+}
+
+// EvaluateIn - execute the value once and the body with the bound name over the values of the domain
+func (l *Let) EvaluateIn(vars map[string]interfaces.Value, d interfaces.Domain) (interfaces.Value, error) {
+	val, err := l.Value.EvaluateIn(vars, d)
+	if err != nil {
+		return nil, err
+	}
+	scope := make(map[string]interfaces.Value, len(vars)+1)
+	for name, v := range vars {
+		scope[name] = v
+	}
+	scope[l.Name] = val
+	return l.Body.EvaluateIn(scope, d)
+}
+
+// GetVarList - the bound name isn't a variable of the body
+func (l *Let) GetVarList(vars map[string]interface{}) {
+	l.Value.GetVarList(vars)
+	body := make(map[string]interface{})
+	l.Body.GetVarList(body)
+	for name := range body {
+		if name != l.Name {
+			vars[name] = struct{}{}
+		}
+	}
+}
+
+// toString conversation
+func (l *Let) String() string {
+	return "( let " + l.Name + " " + l.Value.String() + " " + l.Body.String() + " )"
+}
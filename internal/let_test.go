@@ -0,0 +1,55 @@
+package internal_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/domains/interval"
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+func TestLet(t *testing.T) {
+	p := parser.NewParser()
+	// let m = price - cost in m * m
+	l := internal.Let{
+		Name:  "m",
+		Value: &internal.Node{Op: "-", LExp: &internal.Term{Val: "price"}, RExp: &internal.Term{Val: "cost"}},
+		Body:  &internal.Node{Op: "*", LExp: &internal.Term{Val: "m"}, RExp: &internal.Term{Val: "m"}},
+	}
+
+	vars := map[string]float64{"price": 10, "cost": 7}
+	res, err := l.Evaluate(vars, p)
+	if err != nil {
+		t.Error(err)
+	}
+	if !fuzzyEqual(res, 9) {
+		t.Error("incorrect result = " + strconv.FormatFloat(res, 'e', 4, 64))
+	}
+	if _, ok := vars["m"]; ok {
+		t.Error("input variables were modified")
+	}
+
+	val, err := l.EvaluateIn(map[string]interfaces.Value{"price": interval.New(10, 11), "cost": interval.Point(7)},
+		interval.NewDomain(p.Levels()))
+	if err != nil {
+		t.Error(err)
+	}
+	if x := val.(interval.Interval); !x.Contains(9) || !x.Contains(16) {
+		t.Error("incorrect result = " + x.String())
+	}
+
+	varList := map[string]interface{}{}
+	l.GetVarList(varList)
+	if len(varList) != 2 {
+		t.Error("incorrect map keys count = " + strconv.Itoa(len(varList)))
+	}
+	if _, ok := varList["m"]; ok {
+		t.Error("bound name is reported as variable")
+	}
+
+	if l.String() != "( let m ( - price cost ) ( * m m ) )" {
+		t.Error("incorrect string conversion = " + l.String())
+	}
+}
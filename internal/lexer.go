@@ -0,0 +1,458 @@
+package internal
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TokenKind - kind of lexical token
+type TokenKind int
+
+const (
+	// TokenTerm - number or variable name
+	TokenTerm TokenKind = iota
+	// TokenString - string literal in double quotes
+	TokenString
+	// TokenOperator - operator symbol
+	TokenOperator
+	TokenLParen
+	TokenRParen
+	TokenComma
+	TokenAssign
+	// TokenSeparator - statements separator: ';' or new line
+	TokenSeparator
+	// TokenSuperscript - exponent written with superscript digits: x² or x⁻¹, the value is '2' or '-1'
+	TokenSuperscript
+	TokenEOF
+)
+
+// Token - lexical token and its position (index of rune) in the source string
+type Token struct {
+	Kind TokenKind
+	Val  string
+	Pos  int
+}
+
+// Lexer - splits a string to tokens, operator symbols are matched greedily
+type Lexer struct {
+	symbols []string
+	// Percent - trailing '%' of the number means /100: 15% = 0.15
+	Percent bool
+	// Suffixes - magnitude suffixes of the number: 2k = 2000, 3M = 3000000, also G and T
+	Suffixes bool
+	// Superscripts - superscript digits are exponents: x² = x^2
+	Superscripts bool
+	// Decimal - decimal separator of numbers, '.' if it isn't set
+	Decimal rune
+	// Separator - separator of function arguments, ',' if it isn't set
+	Separator rune
+	// Thousands - optional separator of digit groups in the integer part: 1.234.567,5
+	Thousands rune
+
+	functions map[string]bool
+	aliases   map[string]string
+	alias     []string
+}
+
+// NewLexer - create a Lexer for the operators. Names which contain letters or digits
+// are functions and they are lexed as terms
+func NewLexer(operators []string) *Lexer {
+	l := new(Lexer)
+	l.functions = make(map[string]bool)
+	for _, op := range operators {
+		if isSymbol(op) {
+			l.symbols = append(l.symbols, op)
+		} else {
+			l.functions[op] = true
+		}
+	}
+	sortByLength(l.symbols)
+	return l
+}
+
+func sortByLength(symbols []string) {
+	sort.Slice(symbols, func(i, j int) bool {
+		return len([]rune(symbols[i])) > len([]rune(symbols[j]))
+	})
+}
+
+// SetAliases - set the symbols which are replaced by the text, like '×' by '*' or '√' by 'sqrt'.
+// The text is split to tokens, the name of a function is an unary operator: √x = sqrt(x)
+func (l *Lexer) SetAliases(aliases map[string]string) {
+	l.aliases = aliases
+	l.alias = nil
+	for a := range aliases {
+		if a != "" {
+			l.alias = append(l.alias, a)
+		}
+	}
+	sortByLength(l.alias)
+}
+
+// aliasAt - the longest alias at the position
+func (l *Lexer) aliasAt(str []rune, i int) string {
+	for _, s := range l.alias {
+		r := []rune(s)
+		if i+len(r) <= len(str) && string(str[i:i+len(r)]) == s {
+			return s
+		}
+	}
+	return ""
+}
+
+// expand - tokens of the alias text, all of them have the position of the alias
+func (l *Lexer) expand(alias string, pos int) ([]Token, error) {
+	sub := *l
+	sub.aliases, sub.alias = nil, nil
+	tokens, err := sub.Tokenize(l.aliases[alias])
+	if err != nil {
+		return nil, errors.New("incorrect replacement of '" + alias + "': " + err.Error())
+	}
+	tokens = tokens[:len(tokens)-1]
+	for i := range tokens {
+		tokens[i].Pos = pos
+	}
+	if len(tokens) == 1 && tokens[0].Kind == TokenTerm && l.functions[tokens[0].Val] {
+		tokens[0].Kind = TokenOperator
+	}
+	return tokens, nil
+}
+
+// superscripts - superscript signs and digits
+var superscripts = map[rune]rune{
+	'⁰': '0', '¹': '1', '²': '2', '³': '3', '⁴': '4', '⁵': '5', '⁶': '6', '⁷': '7', '⁸': '8', '⁹': '9',
+	'⁺': '+', '⁻': '-',
+}
+
+// scanSuperscript - exponent like '⁻¹²' at the position, returns its value and end
+func (l *Lexer) scanSuperscript(str []rune, i int) (string, int, bool) {
+	if !l.Superscripts {
+		return "", i, false
+	}
+	j := i
+	val := ""
+	if c := superscripts[runeAt(str, j)]; c == '+' || c == '-' {
+		if c == '-' {
+			val += "-"
+		}
+		j++
+	}
+	start := j
+	for c, ok := superscripts[runeAt(str, j)]; ok && c != '+' && c != '-'; c, ok = superscripts[runeAt(str, j)] {
+		val += string(c)
+		j++
+	}
+	return val, j, j > start
+}
+
+// special - checks that the operator symbol, alias or superscript starts at the position
+func (l *Lexer) special(str []rune, i int) bool {
+	if l.symbolAt(str, i) != "" || l.aliasAt(str, i) != "" {
+		return true
+	}
+	_, ok := superscripts[str[i]]
+	return ok && l.Superscripts
+}
+
+func isSymbol(op string) bool {
+	if op == "" {
+		return false
+	}
+	for _, c := range op {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// symbolAt - the longest operator symbol at the position
+func (l *Lexer) symbolAt(str []rune, i int) string {
+	for _, s := range l.symbols {
+		r := []rune(s)
+		if i+len(r) <= len(str) && string(str[i:i+len(r)]) == s {
+			return s
+		}
+	}
+	return ""
+}
+
+func (l *Lexer) isPunct(c rune) bool {
+	switch c {
+	case '(', ')', ',', ';', '=', '"', '\n', l.separator():
+		return true
+	}
+	return false
+}
+
+func (l *Lexer) decimal() rune {
+	if l.Decimal == 0 {
+		return '.'
+	}
+	return l.Decimal
+}
+
+func (l *Lexer) separator() rune {
+	if l.Separator == 0 {
+		return ','
+	}
+	return l.Separator
+}
+
+// scanGroups - groups of three digits after the thousands separator, returns the end of the integer part
+func (l *Lexer) scanGroups(str []rune, j int) int {
+	for l.Thousands != 0 && runeAt(str, j) == l.Thousands && scanDigits(str, j+1, 10) == j+4 &&
+		!strings.ContainsRune(string(str[j+1:j+4]), '_') {
+		j += 4
+	}
+	return j
+}
+
+// isMantissa - checks that the term is a number followed by the exponent mark, like '1.5e'
+func isMantissa(term []rune) bool {
+	if len(term) < 2 || (term[len(term)-1] != 'e' && term[len(term)-1] != 'E') {
+		return false
+	}
+	_, err := strconv.ParseFloat(string(term[:len(term)-1]), 64)
+	return err == nil && (unicode.IsDigit(term[0]) || term[0] == '.')
+}
+
+// magnitudes - values of the number suffixes
+var magnitudes = map[rune]float64{'k': 1e3, 'M': 1e6, 'G': 1e9, 'T': 1e12}
+
+func isDigitOf(c rune, base int) bool {
+	switch base {
+	case 2:
+		return c == '0' || c == '1'
+	case 8:
+		return '0' <= c && c <= '7'
+	case 16:
+		return unicode.Is(unicode.ASCII_Hex_Digit, c)
+	}
+	return '0' <= c && c <= '9'
+}
+
+// scanDigits - digits of the base with '_' between them, returns the end of digits
+func scanDigits(str []rune, i, base int) int {
+	j := i
+	for j < len(str) && (isDigitOf(str[j], base) ||
+		(str[j] == '_' && j > i && j+1 < len(str) && isDigitOf(str[j+1], base))) {
+		j++
+	}
+	return j
+}
+
+// isOperandStart - checks that the char can start an operand, so '%' before it is the modulo operator
+func isOperandStart(str []rune, i int) bool {
+	for i < len(str) && unicode.IsSpace(str[i]) && str[i] != '\n' {
+		i++
+	}
+	if i == len(str) {
+		return false
+	}
+	c := str[i]
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.' || c == '(' || c == '"'
+}
+
+// scanNumber - numeric literal at the position: decimal number with optional fraction and exponent,
+// integer with 0x, 0b or 0o prefix, '_' between digits, optional magnitude suffix and percent.
+// Returns the value of the literal and its end or the position where the literal is broken.
+// Decimal numbers without extensions keep their text
+func (l *Lexer) scanNumber(str []rune, i int) (string, int, bool) {
+	if !unicode.IsDigit(str[i]) && (str[i] != l.decimal() || i+1 == len(str) || !unicode.IsDigit(str[i+1])) {
+		return "", i, false
+	}
+	var val float64
+	var j int
+	var text string
+	extended := false
+	bases := map[rune]int{'x': 16, 'X': 16, 'b': 2, 'B': 2, 'o': 8, 'O': 8}
+	if base, ok := bases[runeAt(str, i+1)]; ok && str[i] == '0' && isDigitOf(runeAt(str, i+2), base) {
+		j = scanDigits(str, i+2, base)
+		n, err := strconv.ParseUint(strings.ReplaceAll(string(str[i+2:j]), "_", ""), base, 64)
+		if err != nil {
+			return "", i, false
+		}
+		val, extended = float64(n), true
+	} else {
+		j = l.scanGroups(str, scanDigits(str, i, 10))
+		if runeAt(str, j) == l.decimal() && unicode.IsDigit(runeAt(str, j+1)) {
+			j = scanDigits(str, j+1, 10)
+		} else if runeAt(str, j) == l.decimal() && l.decimal() == '.' {
+			// legacy '2.' form
+			j++
+		}
+		if c := runeAt(str, j); c == 'e' || c == 'E' {
+			k := j + 1
+			if c := runeAt(str, k); c == '+' || c == '-' {
+				k++
+			}
+			if unicode.IsDigit(runeAt(str, k)) {
+				j = scanDigits(str, k, 10)
+			}
+		}
+		text = strings.Map(func(c rune) rune {
+			switch c {
+			case '_', l.Thousands:
+				return -1
+			case l.decimal():
+				return '.'
+			}
+			return c
+		}, string(str[i:j]))
+		var err error
+		if val, err = strconv.ParseFloat(text, 64); err != nil {
+			return "", i, false
+		}
+	}
+
+	if m, ok := magnitudes[runeAt(str, j)]; ok && l.Suffixes && !isIdentChar(runeAt(str, j+1)) {
+		val, extended = val*m, true
+		j++
+	}
+	if runeAt(str, j) == '%' && l.Percent && !isOperandStart(str, j+1) {
+		val, extended = val/100, true
+		j++
+	}
+	if (isIdentChar(runeAt(str, j)) && l.aliasAt(str, j) == "") || runeAt(str, j) == '.' ||
+		runeAt(str, j) == l.decimal() || (l.Thousands != 0 && runeAt(str, j) == l.Thousands) {
+		return "", j, false
+	}
+	if !extended {
+		return text, j, true
+	}
+	return strconv.FormatFloat(val, 'g', -1, 64), j, true
+}
+
+func runeAt(str []rune, i int) rune {
+	if i < len(str) {
+		return str[i]
+	}
+	return 0
+}
+
+func isIdentChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_'
+}
+
+// Tokenize - split the string to tokens, the last token is TokenEOF
+func (l *Lexer) Tokenize(s string) ([]Token, error) {
+	str := []rune(s)
+	var tokens []Token
+	level := 0
+	i := 0
+	for i < len(str) {
+		c := str[i]
+		switch {
+		case c == '\n' && level == 0:
+			tokens = append(tokens, Token{TokenSeparator, "\n", i})
+			i++
+			continue
+		case unicode.IsSpace(c):
+			i++
+			continue
+		case c == '"':
+			j := i + 1
+			for j < len(str) && str[j] != '"' {
+				j++
+			}
+			if j == len(str) {
+				return nil, errors.New("incorrect string literal at " + strconv.Itoa(i) + " position")
+			}
+			tokens = append(tokens, Token{TokenString, string(str[i : j+1]), i})
+			i = j + 1
+			continue
+		}
+		if a := l.aliasAt(str, i); a != "" {
+			expanded, err := l.expand(a, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, expanded...)
+			i += len([]rune(a))
+			continue
+		}
+		if val, end, ok := l.scanSuperscript(str, i); ok {
+			tokens = append(tokens, Token{TokenSuperscript, val, i})
+			i = end
+			continue
+		}
+		if sym := l.symbolAt(str, i); sym != "" {
+			tokens = append(tokens, Token{TokenOperator, sym, i})
+			i += len([]rune(sym))
+			continue
+		}
+		switch c {
+		case '(':
+			level++
+			tokens = append(tokens, Token{TokenLParen, "(", i})
+		case ')':
+			level--
+			tokens = append(tokens, Token{TokenRParen, ")", i})
+		case l.separator():
+			tokens = append(tokens, Token{TokenComma, string(c), i})
+		case ';':
+			tokens = append(tokens, Token{TokenSeparator, ";", i})
+		case ',':
+			return nil, errors.New("unexpected ',' at " + strconv.Itoa(i) + " position")
+		case '=':
+			tokens = append(tokens, Token{TokenAssign, "=", i})
+		default:
+			val, end, ok := l.scanNumber(str, i)
+			if ok {
+				tokens = append(tokens, Token{TokenTerm, val, i})
+				i = end
+				continue
+			}
+			if c := runeAt(str, end); end > i && (l.Decimal != 0 || l.Thousands != 0) &&
+				(c == l.decimal() || c == l.Thousands || c == '.') {
+				return nil, errors.New("incorrect number at " + strconv.Itoa(i) + " position")
+			}
+			j := i
+			for j < len(str) && !unicode.IsSpace(str[j]) && !l.isPunct(str[j]) {
+				if l.special(str, j) {
+					// sign of the exponent like '1e-5'
+					if !isMantissa(str[i:j]) || j+1 == len(str) || !unicode.IsDigit(str[j+1]) ||
+						(str[j] != '-' && str[j] != '+') {
+						break
+					}
+				}
+				j++
+			}
+			if j == i {
+				// lone superscript sign without digits
+				return nil, errors.New("unexpected '" + string(c) + "' at " + strconv.Itoa(i) + " position")
+			}
+			tokens = append(tokens, Token{TokenTerm, string(str[i:j]), i})
+			i = j
+			continue
+		}
+		i++
+	}
+	tokens = append(tokens, Token{TokenEOF, "", len(str)})
+	return tokens, nil
+}
+
+// SplitNumber - split the term with a numeric prefix like '2x' to the number and the name: '2', 'x'.
+// Other terms are returned as is
+func SplitNumber(tok Token) []Token {
+	if tok.Kind != TokenTerm || tok.Val == "" || (!unicode.IsDigit([]rune(tok.Val)[0]) && tok.Val[0] != '.') {
+		return []Token{tok}
+	}
+	if _, err := strconv.ParseFloat(tok.Val, 64); err == nil {
+		return []Token{tok}
+	}
+	str := []rune(tok.Val)
+	for j := len(str) - 1; j > 0; j-- {
+		if !unicode.IsLetter(str[j]) && str[j] != '_' {
+			continue
+		}
+		if _, err := strconv.ParseFloat(string(str[:j]), 64); err == nil {
+			return []Token{{TokenTerm, string(str[:j]), tok.Pos}, {TokenTerm, string(str[j:]), tok.Pos + j}}
+		}
+	}
+	return []Token{tok}
+}
@@ -0,0 +1,143 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+func TestTokenize(t *testing.T) {
+	type TestData struct {
+		input  string
+		output []string
+	}
+	data := []TestData{
+		{"", []string{}},
+		{"x*(sqrt(y)+1)", []string{"x", "*", "(", "sqrt", "(", "y", ")", "+", "1", ")"}},
+		{"1.5e-3 - 2E+2", []string{"1.5e-3", "-", "2E+2"}},
+		{"x-y", []string{"x", "-", "y"}},
+		{"e-1", []string{"e", "-", "1"}},
+		{"to(v, \"km / h\")", []string{"to", "(", "v", ",", "\"km / h\"", ")"}},
+		{"a = 1; b = a ** 2\nb", []string{"a", "=", "1", ";", "b", "=", "a", "**", "2", "\n", "b"}},
+		{"f(1,\n 2)", []string{"f", "(", "1", ",", "2", ")"}},
+	}
+
+	lexer := internal.NewLexer([]string{"+", "-", "*", "**", "/", "sqrt"})
+	for _, d := range data {
+		tokens, err := lexer.Tokenize(d.input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if tokens[len(tokens)-1].Kind != internal.TokenEOF {
+			t.Error("the last token is not EOF: '" + d.input + "'")
+		}
+		tokens = tokens[:len(tokens)-1]
+		if len(tokens) != len(d.output) {
+			t.Error("incorrect tokens count of '" + d.input + "'")
+			continue
+		}
+		for i, tok := range tokens {
+			if tok.Val != d.output[i] {
+				t.Error("incorrect token of '" + d.input + "': '" + tok.Val + "', need: '" + d.output[i] + "'")
+			}
+		}
+	}
+
+	tokens, _ := lexer.Tokenize("ab + \"c\"")
+	if tokens[1].Kind != internal.TokenOperator || tokens[1].Pos != 3 || tokens[2].Kind != internal.TokenString {
+		t.Error("incorrect token kind or position")
+	}
+
+	if _, err := lexer.Tokenize("to(v, \"km)"); err == nil {
+		t.Error("incorrect string literal error handling")
+	}
+}
+
+func TestTokenizeSeparator(t *testing.T) {
+	lexer := internal.NewLexer([]string{"*"})
+	lexer.Decimal, lexer.Separator = ',', ';'
+	tokens, err := lexer.Tokenize("f(3,5; x)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the token contains the separator of the locale, so the errors name it
+	if tokens[3].Kind != internal.TokenComma || tokens[3].Val != ";" || tokens[3].Pos != 5 {
+		t.Error("incorrect separator token: '" + tokens[3].Val + "'")
+	}
+}
+
+func TestTokenizeNumbers(t *testing.T) {
+	type TestData struct {
+		input  string
+		output []string
+	}
+	data := []TestData{
+		{"0xFF + 0b101 - 0o17", []string{"255", "+", "5", "-", "15"}},
+		{"1_000_000 * 0x_1", []string{"1000000", "*", "0x_1"}},
+		{"1.5e3 .5 2.", []string{"1.5e3", ".5", "2."}},
+		{"15% * x", []string{"0.15", "*", "x"}},
+		{"10 % 3", []string{"10", "%", "3"}},
+		{"10%3", []string{"10", "%", "3"}},
+		{"2k + 3M - 1.5G", []string{"2000", "+", "3e+06", "-", "1.5e+09"}},
+		{"5km + 2x", []string{"5km", "+", "2x"}},
+		{"1_", []string{"1_"}},
+		{"1.2.3", []string{"1.2.3"}},
+	}
+
+	lexer := internal.NewLexer([]string{"+", "-", "*", "%"})
+	lexer.Percent = true
+	lexer.Suffixes = true
+	for _, d := range data {
+		tokens, err := lexer.Tokenize(d.input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		tokens = tokens[:len(tokens)-1]
+		if len(tokens) != len(d.output) {
+			t.Error("incorrect tokens count of '" + d.input + "'")
+			continue
+		}
+		for i, tok := range tokens {
+			if tok.Val != d.output[i] {
+				t.Error("incorrect token of '" + d.input + "': '" + tok.Val + "', need: '" + d.output[i] + "'")
+			}
+		}
+	}
+
+	// percent and suffixes are disabled by default
+	tokens, _ := internal.NewLexer([]string{"%"}).Tokenize("15% 2k")
+	if tokens[0].Val != "15" || tokens[1].Val != "%" || tokens[2].Val != "2k" {
+		t.Error("percent and suffixes must be disabled by default")
+	}
+}
+
+func TestTokenizeAliases(t *testing.T) {
+	lexer := internal.NewLexer([]string{"*", "-", "^", "sqrt"})
+	lexer.SetAliases(map[string]string{"×": "*", "−": "-", "√": "sqrt", "π": "(3.14)"})
+	lexer.Superscripts = true
+
+	tokens, err := lexer.Tokenize("2×π − √x⁻¹²")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := []internal.TokenKind{internal.TokenTerm, internal.TokenOperator, internal.TokenLParen, internal.TokenTerm,
+		internal.TokenRParen, internal.TokenOperator, internal.TokenOperator, internal.TokenTerm,
+		internal.TokenSuperscript, internal.TokenEOF}
+	vals := []string{"2", "*", "(", "3.14", ")", "-", "sqrt", "x", "-12", ""}
+	positions := []int{0, 1, 2, 2, 2, 4, 6, 7, 8, 11}
+	if len(tokens) != len(kinds) {
+		t.Fatal("incorrect tokens count")
+	}
+	for i, tok := range tokens {
+		if tok.Kind != kinds[i] || tok.Val != vals[i] || tok.Pos != positions[i] {
+			t.Error("incorrect token: '" + tok.Val + "', need: '" + vals[i] + "'")
+		}
+	}
+	for _, input := range []string{"x⁻", "x⁺", "2⁻ + 1"} {
+		if _, err := lexer.Tokenize(input); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+}
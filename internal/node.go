@@ -27,10 +27,23 @@ func (n *Node) Evaluate(vars map[string]float64, p interfaces.ExpParser) (float6
 	if !exist {
 		return 0.0, errors.New("not supported binary operation: '" + string(n.Op) + "'")
 	}
-	result, err := p.GetFunctions()[indx][n.Op](left, right)
+	result, err := Operators(p)[indx][n.Op](left, right)
 	return result, err
 }
 
+// EvaluateIn - execute expression tree over the values of the domain
+func (n *Node) EvaluateIn(vars map[string]interfaces.Value, d interfaces.Domain) (interfaces.Value, error) {
+	left, err := n.LExp.EvaluateIn(vars, d)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.RExp.EvaluateIn(vars, d)
+	if err != nil {
+		return nil, err
+	}
+	return d.Binary(n.Op, left, right)
+}
+
 func (n *Node) GetVarList(vars map[string]interface{}) {
 	n.LExp.GetVarList(vars)
 	n.RExp.GetVarList(vars)
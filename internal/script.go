@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"github.com/overseven/go-math-expression-parser/interfaces"
+)
+
+// Assign - the statement which stores a value of the expression to the variable
+type Assign struct {
+	Name string
+	Exp  interfaces.Expression
+}
+
+// Evaluate - execute expression and store the result to vars
+func (a *Assign) Evaluate(vars map[string]float64, p interfaces.ExpParser) (float64, error) {
+	val, err := a.Exp.Evaluate(vars, p)
+	if err != nil {
+		return 0.0, err
+	}
+	vars[a.Name] = val
+	return val, nil
+}
+
+// EvaluateIn - execute expression over the values of the domain and store the result to vars
+func (a *Assign) EvaluateIn(vars map[string]interfaces.Value, d interfaces.Domain) (interfaces.Value, error) {
+	val, err := a.Exp.EvaluateIn(vars, d)
+	if err != nil {
+		return nil, err
+	}
+	vars[a.Name] = val
+	return val, nil
+}
+
+func (a *Assign) GetVarList(vars map[string]interface{}) {
+	a.Exp.GetVarList(vars)
+}
+
+// toString conversation
+func (a *Assign) String() string {
+	return "( = " + a.Name + " " + a.Exp.String() + " )"
+}
+
+// Script - the sequence of statements, the value of the last one is the result
+type Script struct {
+	Stmts []interfaces.Expression
+}
+
+// Run - execute statements, return the result and the final variables environment
+func (s *Script) Run(vars map[string]float64, p interfaces.ExpParser) (float64, map[string]float64, error) {
+	env := make(map[string]float64, len(vars))
+	for name, val := range vars {
+		env[name] = val
+	}
+	result := 0.0
+	for _, stmt := range s.Stmts {
+		val, err := stmt.Evaluate(env, p)
+		if err != nil {
+			return 0.0, nil, err
+		}
+		result = val
+	}
+	return result, env, nil
+}
+
+// Evaluate - execute statements and return the value of the last one, vars are not modified
+func (s *Script) Evaluate(vars map[string]float64, p interfaces.ExpParser) (float64, error) {
+	result, _, err := s.Run(vars, p)
+	return result, err
+}
+
+// EvaluateIn - execute statements over the values of the domain, vars are not modified
+func (s *Script) EvaluateIn(vars map[string]interfaces.Value, d interfaces.Domain) (interfaces.Value, error) {
+	env := make(map[string]interfaces.Value, len(vars))
+	for name, val := range vars {
+		env[name] = val
+	}
+	var result interfaces.Value
+	for _, stmt := range s.Stmts {
+		val, err := stmt.EvaluateIn(env, d)
+		if err != nil {
+			return nil, err
+		}
+		result = val
+	}
+	if result == nil {
+		return d.Const(0.0)
+	}
+	return result, nil
+}
+
+// GetVarList - collect only external variables, which are used before the assignment
+func (s *Script) GetVarList(vars map[string]interface{}) {
+	assigned := make(map[string]interface{})
+	for _, stmt := range s.Stmts {
+		used := make(map[string]interface{})
+		stmt.GetVarList(used)
+		for name := range used {
+			if _, ok := assigned[name]; !ok {
+				vars[name] = struct{}{}
+			}
+		}
+		if a, ok := stmt.(*Assign); ok {
+			assigned[a.Name] = struct{}{}
+		}
+	}
+}
+
+// toString conversation
+func (s *Script) String() string {
+	str := ""
+	for i, stmt := range s.Stmts {
+		if i > 0 {
+			str += "; "
+		}
+		str += stmt.String()
+	}
+	return str
+}
@@ -0,0 +1,80 @@
+package internal_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+	"github.com/overseven/go-math-expression-parser/parser"
+)
+
+func TestAssignEvaluate(t *testing.T) {
+	p := parser.NewParser()
+	a := internal.Assign{Name: "x", Exp: &internal.Node{Op: "*", LExp: &internal.Term{Val: "y"}, RExp: &internal.Term{Val: "2"}}}
+
+	vars := map[string]float64{"y": 4}
+	res, err := a.Evaluate(vars, p)
+	if err != nil {
+		t.Error(err)
+	}
+	if !fuzzyEqual(res, 8) || !fuzzyEqual(vars["x"], 8) {
+		t.Error("incorrect result = " + strconv.FormatFloat(res, 'e', 4, 64))
+	}
+
+	if _, err := a.Evaluate(map[string]float64{}, p); err == nil {
+		t.Error("incorrect error handling!")
+	}
+
+	if a.String() != "( = x ( * y 2 ) )" {
+		t.Error("incorrect string conversion = " + a.String())
+	}
+}
+
+func TestScript(t *testing.T) {
+	p := parser.NewParser()
+	// tax = price * 0.2; total = price + tax; total * qty
+	script := internal.Script{Stmts: []interfaces.Expression{
+		&internal.Assign{Name: "tax", Exp: &internal.Node{Op: "*", LExp: &internal.Term{Val: "price"}, RExp: &internal.Term{Val: "0.2"}}},
+		&internal.Assign{Name: "total", Exp: &internal.Node{Op: "+", LExp: &internal.Term{Val: "price"}, RExp: &internal.Term{Val: "tax"}}},
+		&internal.Node{Op: "*", LExp: &internal.Term{Val: "total"}, RExp: &internal.Term{Val: "qty"}},
+	}}
+
+	vars := map[string]float64{"price": 10, "qty": 3}
+	res, env, err := script.Run(vars, p)
+	if err != nil {
+		t.Error(err)
+	}
+	if !fuzzyEqual(res, 36) {
+		t.Error("incorrect result = " + strconv.FormatFloat(res, 'e', 4, 64))
+	}
+	if !fuzzyEqual(env["tax"], 2) || !fuzzyEqual(env["total"], 12) || !fuzzyEqual(env["qty"], 3) {
+		t.Error("incorrect environment")
+	}
+	if _, ok := vars["tax"]; ok {
+		t.Error("input variables were modified")
+	}
+
+	varList := map[string]interface{}{}
+	script.GetVarList(varList)
+	if len(varList) != 2 {
+		t.Error("incorrect map keys count = " + strconv.Itoa(len(varList)))
+	}
+	if _, ok := varList["tax"]; ok {
+		t.Error("assigned variable is reported as external")
+	}
+
+	if script.String() != "( = tax ( * price 0.2 ) ); ( = total ( + price tax ) ); ( * total qty )" {
+		t.Error("incorrect string conversion = " + script.String())
+	}
+
+	// variable is used before assignment
+	script = internal.Script{Stmts: []interfaces.Expression{
+		&internal.Assign{Name: "x", Exp: &internal.Node{Op: "+", LExp: &internal.Term{Val: "x"}, RExp: &internal.Term{Val: "1"}}},
+	}}
+	varList = map[string]interface{}{}
+	script.GetVarList(varList)
+	if _, ok := varList["x"]; !ok {
+		t.Error("not found x")
+	}
+}
@@ -13,7 +13,7 @@ type Term struct {
 }
 
 func (t *Term) GetVarList(vars map[string]interface{}) {
-	if t.Val == "" {
+	if t.Val == "" || IsQuoted(t.Val) {
 		return
 	}
 	if _, err := strconv.ParseFloat(t.Val, 64); err == nil {
@@ -31,6 +31,9 @@ func (t *Term) Evaluate(vars map[string]float64, p interfaces.ExpParser) (float6
 	if val, err := strconv.ParseFloat(t.Val, 64); err == nil {
 		return val, nil
 	}
+	if IsQuoted(t.Val) {
+		return 0.0, errors.New("string " + t.Val + " can't be evaluated as a number")
+	}
 	val, ok := vars[t.Val]
 	if !ok {
 		return 0.0, errors.New("value '" + t.Val + " not found in map")
@@ -38,7 +41,26 @@ func (t *Term) Evaluate(vars map[string]float64, p interfaces.ExpParser) (float6
 	return val, nil
 }
 
+// EvaluateIn - return a domain value of the Term
+func (t *Term) EvaluateIn(vars map[string]interfaces.Value, d interfaces.Domain) (interfaces.Value, error) {
+	if t.Val == "" {
+		return d.Const(0.0)
+	}
+	if val, err := strconv.ParseFloat(t.Val, 64); err == nil {
+		return d.Const(val)
+	}
+	if val, ok := vars[t.Val]; ok {
+		return val, nil
+	}
+	return d.Literal(t.Val)
+}
+
 // toString conversation
 func (t *Term) String() string {
 	return t.Val
 }
+
+// IsQuoted - checks that the term is a string literal in double quotes
+func IsQuoted(val string) bool {
+	return len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"'
+}
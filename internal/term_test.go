@@ -36,6 +36,14 @@ func TestTermGetVarList(t *testing.T) {
 	if len(vars) != 1 {
 		t.Error("incorrect map keys count = " + strconv.Itoa(len(vars)))
 	}
+
+	term4 := internal.Term{Val: "\"km/h\""}
+	vars = map[string]interface{}{}
+	term4.GetVarList(vars)
+
+	if len(vars) != 0 {
+		t.Error("incorrect map keys count = " + strconv.Itoa(len(vars)))
+	}
 }
 
 func TestTermEvaluate(t *testing.T) {
@@ -30,6 +30,15 @@ func (u *Unary) Evaluate(vars map[string]float64, p interfaces.ExpParser) (float
 	return result, err
 }
 
+// EvaluateIn - execute unary operator over the values of the domain
+func (u *Unary) EvaluateIn(vars map[string]interfaces.Value, d interfaces.Domain) (interfaces.Value, error) {
+	val, err := u.Exp.EvaluateIn(vars, d)
+	if err != nil {
+		return nil, err
+	}
+	return d.Unary(u.Op, val)
+}
+
 // toString conversation
 func (u *Unary) String() string {
 	return "( " + string(u.Op) + " " + u.Exp.String() + " )"
@@ -0,0 +1,585 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/overseven/go-math-expression-parser/funcs"
+	dfuncs "github.com/overseven/go-math-expression-parser/funcs/basic"
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// ErrNull - the error of the row, which has the null value of the column.
+// Like other errors it's skipped by coalesce and replaced by the fallback of iferror
+var ErrNull = errors.New("value is null")
+
+// DefaultChunkSize - count of rows, which are evaluated together
+const DefaultChunkSize = 4096
+
+// ColumnOptions - options of the columnar evaluation
+type ColumnOptions struct {
+	// Nulls - masks of the columns, true is the null value of the row
+	Nulls map[string][]bool
+	// Workers - count of goroutines evaluating the chunks of rows, they are evaluated by the caller if it's 0 or 1.
+	// The functions must be safe for concurrent use
+	Workers int
+	// ChunkSize - count of rows, which are evaluated together, DefaultChunkSize is used if it's 0
+	ChunkSize int
+}
+
+// EvaluateColumns - execute expression over the columns of values, the result and the error of each row are the
+// same as of Evaluate() with the values of the row. errs is nil if all rows are evaluated without errors
+func (p *Parser) EvaluateColumns(columns map[string][]float64) (result []float64, errs []error) {
+	return p.EvaluateColumnsWith(columns, ColumnOptions{})
+}
+
+// EvaluateColumnsWith - execute expression over the columns with the null masks and the goroutines.
+// Each node is evaluated for the chunk of rows at once, the arguments of special forms are evaluated only for
+// the rows, which need them. The special form is called again for the rows, which need the next argument,
+// so it must not have side effects
+func (p *Parser) EvaluateColumnsWith(columns map[string][]float64, opts ColumnOptions) (result []float64, errs []error) {
+	rows := 0
+	for _, col := range columns {
+		if len(col) > rows {
+			rows = len(col)
+		}
+	}
+	result = make([]float64, rows)
+	if err := checkColumns(columns, opts.Nulls, rows); err != nil {
+		errs = make([]error, rows)
+		for i := range errs {
+			errs[i] = err
+		}
+		return result, errs
+	}
+
+	size := opts.ChunkSize
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	chunks := (rows + size - 1) / size
+	chunkErrs := make([][]error, chunks)
+	evaluate := func(b *batch, chunk int) {
+		lo, hi := chunk*size, (chunk+1)*size
+		if hi > rows {
+			hi = rows
+		}
+		b.lo, b.hi = lo, hi
+		b.scope = make(map[string]vector)
+		v := b.eval(p.Expression)
+		copy(result[lo:hi], v.vals)
+		if v.errs != nil {
+			for i, err := range v.errs {
+				if err != nil {
+					result[lo+i] = 0.0
+				}
+			}
+			chunkErrs[chunk] = v.errs
+		}
+	}
+
+	workers := opts.Workers
+	if workers > chunks {
+		workers = chunks
+	}
+	if workers <= 1 {
+		b := &batch{p: p, columns: columns, nulls: opts.Nulls}
+		for chunk := 0; chunk < chunks; chunk++ {
+			evaluate(b, chunk)
+		}
+	} else {
+		next := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				b := &batch{p: p, columns: columns, nulls: opts.Nulls}
+				for chunk := range next {
+					evaluate(b, chunk)
+				}
+			}()
+		}
+		for chunk := 0; chunk < chunks; chunk++ {
+			next <- chunk
+		}
+		close(next)
+		wg.Wait()
+	}
+
+	for chunk, chunkErr := range chunkErrs {
+		if chunkErr == nil {
+			continue
+		}
+		if errs == nil {
+			errs = make([]error, rows)
+		}
+		copy(errs[chunk*size:], chunkErr)
+	}
+	return result, errs
+}
+
+// checkColumns - all columns and masks have the same count of rows
+func checkColumns(columns map[string][]float64, nulls map[string][]bool, rows int) error {
+	for name, col := range columns {
+		if len(col) != rows {
+			return errors.New("incorrect length of column '" + name + "': " + strconv.Itoa(len(col)) +
+				", need: " + strconv.Itoa(rows))
+		}
+	}
+	for name, mask := range nulls {
+		if _, ok := columns[name]; !ok {
+			return errors.New("null mask of unknown column '" + name + "'")
+		}
+		if len(mask) != rows {
+			return errors.New("incorrect length of null mask of '" + name + "': " + strconv.Itoa(len(mask)) +
+				", need: " + strconv.Itoa(rows))
+		}
+	}
+	return nil
+}
+
+// vector - values of the node for the rows of the chunk, errs is nil if no row has error
+type vector struct {
+	vals []float64
+	errs []error
+	// the buffers are owned by the node, so they may be modified and reused
+	owned, ownedErrs bool
+}
+
+// err - the error of the row
+func (v *vector) err(i int) error {
+	if v.errs == nil {
+		return nil
+	}
+	return v.errs[i]
+}
+
+// fail - set the error of the row, the shared errors of other nodes aren't modified
+func (v *vector) fail(i int, err error) {
+	if !v.ownedErrs {
+		errs := make([]error, len(v.vals))
+		copy(errs, v.errs)
+		v.errs, v.ownedErrs = errs, true
+	}
+	v.errs[i] = err
+}
+
+// batch - evaluator of the chunks of rows, it reuses the buffers of the values
+type batch struct {
+	p       *Parser
+	columns map[string][]float64
+	nulls   map[string][]bool
+	lo, hi  int
+	// rows of the columns, which are evaluated instead of lo..hi, it's used for the arguments of special forms
+	index []int
+	// vectors of the let-bindings and the assignments
+	scope map[string]vector
+	free  [][]float64
+}
+
+// size - count of the evaluated rows
+func (b *batch) size() int {
+	if b.index != nil {
+		return len(b.index)
+	}
+	return b.hi - b.lo
+}
+
+// row - the row of the columns
+func (b *batch) row(i int) int {
+	if b.index != nil {
+		return b.index[i]
+	}
+	return b.lo + i
+}
+
+// subset - the batch of the rows of this batch, the vectors of the scope are gathered
+func (b *batch) subset(rows []int) *batch {
+	s := &batch{p: b.p, columns: b.columns, nulls: b.nulls, index: make([]int, len(rows)),
+		scope: make(map[string]vector, len(b.scope))}
+	for i, row := range rows {
+		s.index[i] = b.row(row)
+	}
+	for name, v := range b.scope {
+		sv := vector{vals: make([]float64, len(rows))}
+		for i, row := range rows {
+			sv.vals[i] = v.vals[row]
+			if err := v.err(row); err != nil {
+				sv.fail(i, err)
+			}
+		}
+		s.scope[name] = sv
+	}
+	return s
+}
+
+func (b *batch) alloc() vector {
+	n := b.size()
+	if len(b.free) > 0 {
+		buf := b.free[len(b.free)-1]
+		b.free = b.free[:len(b.free)-1]
+		if cap(buf) >= n {
+			return vector{vals: buf[:n], owned: true}
+		}
+	}
+	return vector{vals: make([]float64, n), owned: true}
+}
+
+func (b *batch) release(v vector) {
+	if v.owned {
+		b.free = append(b.free, v.vals)
+	}
+}
+
+// constant - the same value or the same error of all rows
+func (b *batch) constant(val float64, err error) vector {
+	v := b.alloc()
+	for i := range v.vals {
+		v.vals[i] = val
+	}
+	if err != nil {
+		v.errs, v.ownedErrs = make([]error, len(v.vals)), true
+		for i := range v.errs {
+			v.errs[i] = err
+		}
+	}
+	return v
+}
+
+// result - the vector with the errors of the operands, the first error of the row is kept
+func (b *batch) result(operands ...vector) vector {
+	v := b.alloc()
+	for _, op := range operands {
+		if op.errs == nil {
+			continue
+		}
+		if v.errs == nil {
+			v.errs = op.errs
+			continue
+		}
+		for i, err := range op.errs {
+			if err != nil && v.errs[i] == nil {
+				v.fail(i, err)
+			}
+		}
+	}
+	return v
+}
+
+func (b *batch) eval(exp interfaces.Expression) vector {
+	switch e := exp.(type) {
+	case *internal.Term:
+		return b.term(e)
+
+	case *internal.Node:
+		return b.binary(e)
+
+	case *internal.Unary:
+		x := b.eval(e.Exp)
+		defer b.release(x)
+		indx, exist := internal.UnaryOperatorExist(e.Op, b.p)
+		if !exist {
+			return b.failed(b.result(x), errors.New("not supported unary operation: '"+e.Op+"'"))
+		}
+		return b.call(b.p.Levels()[indx][e.Op], e.Op, []vector{x})
+
+	case interfaces.Function:
+		return b.function(e)
+
+	case *internal.Let:
+		value := b.eval(e.Value)
+		value.owned = false
+		saved, shadowed := b.scope[e.Name]
+		b.scope[e.Name] = value
+		v := b.eval(e.Body)
+		if shadowed {
+			b.scope[e.Name] = saved
+		} else {
+			delete(b.scope, e.Name)
+		}
+		// the body isn't evaluated if the value fails
+		for i, err := range value.errs {
+			if err != nil {
+				v.fail(i, err)
+			}
+		}
+		return v
+
+	case *internal.Assign:
+		v := b.eval(e.Exp)
+		v.owned = false
+		b.scope[e.Name] = v
+		return v
+
+	case *internal.Script:
+		if len(e.Stmts) == 0 {
+			return b.constant(0.0, nil)
+		}
+		scope := b.scope
+		b.scope = make(map[string]vector, len(scope))
+		for name, v := range scope {
+			b.scope[name] = v
+		}
+		// the statements aren't executed after the error of the row
+		var failed []error
+		var v vector
+		for i, stmt := range e.Stmts {
+			if i > 0 {
+				b.release(v)
+			}
+			v = b.eval(stmt)
+			for row, err := range failed {
+				if err != nil {
+					v.fail(row, err)
+				}
+			}
+			failed = v.errs
+		}
+		b.scope = scope
+		return v
+	}
+	return b.constant(0.0, errors.New("not supported node '"+exp.String()+"' in columnar evaluation"))
+}
+
+// failed - set the error of the rows, which haven't errors of the operands
+func (b *batch) failed(v vector, err error) vector {
+	for i := range v.vals {
+		if v.err(i) == nil {
+			v.fail(i, err)
+		}
+	}
+	return v
+}
+
+func (b *batch) term(t *internal.Term) vector {
+	if v, ok := b.scope[t.Val]; ok {
+		return vector{vals: v.vals, errs: v.errs}
+	}
+	if col, ok := b.columns[t.Val]; ok && !internal.IsQuoted(t.Val) && !isNumber(t.Val) {
+		var v vector
+		if b.index == nil {
+			v.vals = col[b.lo:b.hi]
+		} else {
+			v = b.alloc()
+			for i, row := range b.index {
+				v.vals[i] = col[row]
+			}
+		}
+		if mask := b.nulls[t.Val]; mask != nil {
+			for i := range v.vals {
+				if mask[b.row(i)] {
+					v.fail(i, ErrNull)
+				}
+			}
+		}
+		return v
+	}
+	// numbers, strings and unknown variables
+	return b.constant(t.Evaluate(nil, b.p))
+}
+
+func (b *batch) binary(n *internal.Node) vector {
+	x := b.eval(n.LExp)
+	defer b.release(x)
+	y := b.eval(n.RExp)
+	defer b.release(y)
+	v := b.result(x, y)
+	indx, exist := internal.BinaryOperatorExist(n.Op, b.p)
+	if !exist {
+		return b.failed(v, errors.New("not supported binary operation: '"+n.Op+"'"))
+	}
+	f := b.p.Levels()[indx][n.Op]
+	fast, ok := binaryOps[n.Op]
+	if !ok || !same(f, fast.def) {
+		return b.rows(v, f, []vector{x, y})
+	}
+
+	out, xs, ys := v.vals, x.vals, y.vals
+	switch n.Op {
+	case "+":
+		for i := range out {
+			out[i] = xs[i] + ys[i]
+		}
+	case "-":
+		for i := range out {
+			out[i] = xs[i] - ys[i]
+		}
+	case "*":
+		for i := range out {
+			out[i] = xs[i] * ys[i]
+		}
+	case "/":
+		_, err := dfuncs.Div(1, 0)
+		for i := range out {
+			if ys[i] == 0 {
+				if v.err(i) == nil {
+					v.fail(i, err)
+				}
+				continue
+			}
+			out[i] = xs[i] / ys[i]
+		}
+	default:
+		op := fast.op
+		for i := range out {
+			if v.err(i) != nil {
+				continue
+			}
+			res, err := op(xs[i], ys[i])
+			if err != nil {
+				v.fail(i, err)
+			}
+			out[i] = res
+		}
+	}
+	return v
+}
+
+// call - the function of the parser for each row, the default unary operators are called directly
+func (b *batch) call(f funcs.FuncType, name string, args []vector) vector {
+	v := b.result(args...)
+	if fast, ok := unaryOps[name]; ok && len(args) == 1 && same(f, fast.def) {
+		out, xs := v.vals, args[0].vals
+		switch name {
+		case "-":
+			for i := range out {
+				out[i] = -xs[i]
+			}
+		case "+":
+			copy(out, xs)
+		default:
+			op := fast.op
+			for i := range out {
+				if v.err(i) != nil {
+					continue
+				}
+				res, err := op(xs[i])
+				if err != nil {
+					v.fail(i, err)
+				}
+				out[i] = res
+			}
+		}
+		return v
+	}
+	return b.rows(v, f, args)
+}
+
+// rows - call the function for each row without errors of the arguments
+func (b *batch) rows(v vector, f funcs.FuncType, args []vector) vector {
+	vals := make([]float64, len(args))
+	for i := range v.vals {
+		if v.err(i) != nil {
+			continue
+		}
+		for j, arg := range args {
+			vals[j] = arg.vals[i]
+		}
+		res, err := f(vals...)
+		if err != nil {
+			v.fail(i, err)
+		}
+		v.vals[i] = res
+	}
+	return v
+}
+
+func (b *batch) function(fn interfaces.Function) vector {
+	name := fn.GetOperation()
+	if form, ok := b.p.SpecialForms[name]; ok {
+		return b.special(form, fn.GetArgs())
+	}
+	args := make([]vector, len(fn.GetArgs()))
+	for i, arg := range fn.GetArgs() {
+		args[i] = b.eval(arg)
+	}
+	defer func() {
+		for _, arg := range args {
+			b.release(arg)
+		}
+	}()
+
+	f, ok := b.p.Operators[0][name]
+	if !ok {
+		return b.failed(b.result(args...), errors.New("not supported function: '"+name+"'"))
+	}
+	return b.call(f, name, args)
+}
+
+// errNotEvaluated - the thunk of the argument, which isn't evaluated for the row yet
+var errNotEvaluated = errors.New("argument isn't evaluated")
+
+// special - the special form for each row. The form is called for the rows, the first argument, which isn't
+// evaluated for the row, is evaluated for all rows needing it, and the form is called again for these rows
+func (b *batch) special(form funcs.SpecialFormType, exps []interfaces.Expression) vector {
+	n := b.size()
+	args := make([]vector, len(exps))
+	evaluated := make([][]bool, len(exps))
+	// the row of the call and the argument needed by it, -1 if all needed arguments are evaluated
+	row, missing := 0, -1
+	thunks := make([]funcs.Thunk, len(exps))
+	for j := range exps {
+		j := j
+		thunks[j] = func() (float64, error) {
+			if missing >= 0 {
+				return 0, errNotEvaluated
+			}
+			if evaluated[j] == nil || !evaluated[j][row] {
+				missing = j
+				return 0, errNotEvaluated
+			}
+			return args[j].vals[row], args[j].err(row)
+		}
+	}
+
+	v := b.alloc()
+	pending := make([]int, n)
+	for i := range pending {
+		pending[i] = i
+	}
+	for len(pending) > 0 {
+		needs := make([][]int, len(exps))
+		var next []int
+		for _, row = range pending {
+			missing = -1
+			res, err := form(thunks...)
+			if missing >= 0 {
+				needs[missing] = append(needs[missing], row)
+				next = append(next, row)
+				continue
+			}
+			if err != nil {
+				v.fail(row, err)
+			}
+			v.vals[row] = res
+		}
+		for j, rows := range needs {
+			if len(rows) == 0 {
+				continue
+			}
+			if evaluated[j] == nil {
+				args[j] = vector{vals: make([]float64, n)}
+				evaluated[j] = make([]bool, n)
+			}
+			// the rows are sorted, so all rows of the batch are evaluated without gathering
+			sub := b
+			if len(rows) < n {
+				sub = b.subset(rows)
+			}
+			x := sub.eval(exps[j])
+			for i, row := range rows {
+				args[j].vals[row] = x.vals[i]
+				if err := x.err(i); err != nil {
+					args[j].fail(row, err)
+				}
+				evaluated[j][row] = true
+			}
+			sub.release(x)
+		}
+		pending = next
+	}
+	return v
+}
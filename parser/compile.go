@@ -0,0 +1,302 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+
+	"github.com/overseven/go-math-expression-parser/funcs"
+	dfuncs "github.com/overseven/go-math-expression-parser/funcs/basic"
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// closure - compiled node, the frame contains the values of the variables and of the let-bindings
+type closure func(frame []float64) (float64, error)
+
+// binaryOps - the default binary operators, they are called directly without the slice of arguments
+var binaryOps = map[string]struct {
+	def funcs.FuncType
+	op  func(x, y float64) (float64, error)
+}{
+	"+":  {dfuncs.Sum, func(x, y float64) (float64, error) { return x + y, nil }},
+	"-":  {dfuncs.Sub, func(x, y float64) (float64, error) { return x - y, nil }},
+	"*":  {dfuncs.Mult, func(x, y float64) (float64, error) { return x * y, nil }},
+	"/":  {dfuncs.Div, func(x, y float64) (float64, error) { return dfuncs.Div(x, y) }},
+	"%":  {dfuncs.DivReminder, func(x, y float64) (float64, error) { return dfuncs.DivReminder(x, y) }},
+	"^":  {dfuncs.Pow, func(x, y float64) (float64, error) { return dfuncs.Pow(x, y) }},
+	"<":  {dfuncs.Less, func(x, y float64) (float64, error) { return dfuncs.Less(x, y) }},
+	"<=": {dfuncs.LessOrEqual, func(x, y float64) (float64, error) { return dfuncs.LessOrEqual(x, y) }},
+	">":  {dfuncs.Greater, func(x, y float64) (float64, error) { return dfuncs.Greater(x, y) }},
+	">=": {dfuncs.GreaterOrEqual, func(x, y float64) (float64, error) { return dfuncs.GreaterOrEqual(x, y) }},
+	"==": {dfuncs.Equal, func(x, y float64) (float64, error) { return dfuncs.Equal(x, y) }},
+	"!=": {dfuncs.NotEqual, func(x, y float64) (float64, error) { return dfuncs.NotEqual(x, y) }},
+}
+
+// unaryOps - the default unary operators and functions of one argument
+var unaryOps = map[string]struct {
+	def funcs.FuncType
+	op  func(x float64) (float64, error)
+}{
+	"+":    {dfuncs.UnarySum, func(x float64) (float64, error) { return x, nil }},
+	"-":    {dfuncs.UnarySub, func(x float64) (float64, error) { return -x, nil }},
+	"sqrt": {dfuncs.Sqrt, func(x float64) (float64, error) { return dfuncs.Sqrt(x) }},
+	"abs":  {dfuncs.Abs, func(x float64) (float64, error) { return dfuncs.Abs(x) }},
+}
+
+// same - checks that the function of the parser is the default one
+func same(f, def funcs.FuncType) bool {
+	return reflect.ValueOf(f).Pointer() == reflect.ValueOf(def).Pointer()
+}
+
+type compiler struct {
+	p *Parser
+	// slots of the variables, let-bindings and assignments in the frame
+	scope map[string]int
+	slots int
+	// the frame is modified by let-bindings or assignments, so it's the copy of the values
+	writes bool
+}
+
+// Compile - the expression as nested closures. The variables are resolved to the indexes of the values in the
+// returned order of GetVarList(), numbers are parsed once and the functions of the parser are bound at compile time,
+// so the later changes of the parser don't affect the compiled function. The results and the errors are the same
+// as of Evaluate(). The compiled function is safe for concurrent use, if the functions of the parser are
+func (p *Parser) Compile(exp interfaces.Expression) (func(values []float64) (float64, error), []string, error) {
+	vars := GetVarList(exp)
+	c := compiler{p: p, scope: make(map[string]int, len(vars)), slots: len(vars)}
+	for i, name := range vars {
+		c.scope[name] = i
+	}
+	f, err := c.compile(exp)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	count, slots, writes := len(vars), c.slots, c.writes
+	return func(values []float64) (float64, error) {
+		if len(values) != count {
+			return 0, errors.New("incorrect count of values: " + strconv.Itoa(len(values)) + ", need: " +
+				strconv.Itoa(count))
+		}
+		if !writes {
+			return f(values)
+		}
+		frame := make([]float64, slots)
+		copy(frame, values)
+		return f(frame)
+	}, vars, nil
+}
+
+func (c *compiler) compile(exp interfaces.Expression) (closure, error) {
+	switch e := exp.(type) {
+	case *internal.Term:
+		return c.term(e), nil
+
+	case *internal.Node:
+		return c.binary(e)
+
+	case *internal.Unary:
+		x, err := c.compile(e.Exp)
+		if err != nil {
+			return nil, err
+		}
+		indx, exist := internal.UnaryOperatorExist(e.Op, c.p)
+		if !exist {
+			return nil, errors.New("not supported unary operation: '" + e.Op + "'")
+		}
+		return c.call(c.p.Levels()[indx][e.Op], e.Op, []closure{x}), nil
+
+	case interfaces.Function:
+		return c.function(e)
+
+	case *internal.Let:
+		value, err := c.compile(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		slot := c.slots
+		c.slots++
+		c.writes = true
+		saved, shadowed := c.scope[e.Name]
+		c.scope[e.Name] = slot
+		body, err := c.compile(e.Body)
+		if shadowed {
+			c.scope[e.Name] = saved
+		} else {
+			delete(c.scope, e.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return func(frame []float64) (float64, error) {
+			val, err := value(frame)
+			if err != nil {
+				return 0.0, err
+			}
+			frame[slot] = val
+			return body(frame)
+		}, nil
+
+	case *internal.Assign:
+		value, err := c.compile(e.Exp)
+		if err != nil {
+			return nil, err
+		}
+		slot, ok := c.scope[e.Name]
+		if !ok {
+			slot = c.slots
+			c.slots++
+			c.scope[e.Name] = slot
+		}
+		c.writes = true
+		return func(frame []float64) (float64, error) {
+			val, err := value(frame)
+			if err != nil {
+				return 0.0, err
+			}
+			frame[slot] = val
+			return val, nil
+		}, nil
+
+	case *internal.Script:
+		stmts := make([]closure, len(e.Stmts))
+		for i, stmt := range e.Stmts {
+			f, err := c.compile(stmt)
+			if err != nil {
+				return nil, err
+			}
+			stmts[i] = f
+		}
+		return func(frame []float64) (float64, error) {
+			result := 0.0
+			for _, stmt := range stmts {
+				val, err := stmt(frame)
+				if err != nil {
+					return 0.0, err
+				}
+				result = val
+			}
+			return result, nil
+		}, nil
+	}
+	return nil, errors.New("not supported node '" + exp.String() + "' for compilation")
+}
+
+func (c *compiler) term(t *internal.Term) closure {
+	if slot, ok := c.scope[t.Val]; ok && !internal.IsQuoted(t.Val) && !isNumber(t.Val) {
+		return func(frame []float64) (float64, error) {
+			return frame[slot], nil
+		}
+	}
+	// numbers are parsed once, strings and unknown variables return the errors of Evaluate()
+	val, err := t.Evaluate(nil, c.p)
+	return func([]float64) (float64, error) {
+		return val, err
+	}
+}
+
+func (c *compiler) binary(n *internal.Node) (closure, error) {
+	x, err := c.compile(n.LExp)
+	if err != nil {
+		return nil, err
+	}
+	y, err := c.compile(n.RExp)
+	if err != nil {
+		return nil, err
+	}
+	indx, exist := internal.BinaryOperatorExist(n.Op, c.p)
+	if !exist {
+		return nil, errors.New("not supported binary operation: '" + n.Op + "'")
+	}
+	f := c.p.Levels()[indx][n.Op]
+	if fast, ok := binaryOps[n.Op]; ok && same(f, fast.def) {
+		op := fast.op
+		return func(frame []float64) (float64, error) {
+			left, err := x(frame)
+			if err != nil {
+				return 0.0, err
+			}
+			right, err := y(frame)
+			if err != nil {
+				return 0.0, err
+			}
+			return op(left, right)
+		}, nil
+	}
+	return c.call(f, n.Op, []closure{x, y}), nil
+}
+
+// call - the bound function of the parser, the default unary operators are called directly
+func (c *compiler) call(f funcs.FuncType, name string, args []closure) closure {
+	if fast, ok := unaryOps[name]; ok && len(args) == 1 && same(f, fast.def) {
+		op, x := fast.op, args[0]
+		return func(frame []float64) (float64, error) {
+			val, err := x(frame)
+			if err != nil {
+				return 0.0, err
+			}
+			return op(val)
+		}
+	}
+	return func(frame []float64) (float64, error) {
+		vals := make([]float64, len(args))
+		for i, arg := range args {
+			val, err := arg(frame)
+			if err != nil {
+				return 0.0, err
+			}
+			vals[i] = val
+		}
+		return f(vals...)
+	}
+}
+
+func (c *compiler) function(fn interfaces.Function) (closure, error) {
+	name := fn.GetOperation()
+	args := make([]closure, len(fn.GetArgs()))
+	for i, arg := range fn.GetArgs() {
+		f, err := c.compile(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = f
+	}
+
+	if form, ok := c.p.SpecialForms[name]; ok {
+		if name == "if" && len(args) == 3 && sameForm(form, dfuncs.If) {
+			cond, then, otherwise := args[0], args[1], args[2]
+			return func(frame []float64) (float64, error) {
+				val, err := cond(frame)
+				if err != nil {
+					return 0.0, err
+				}
+				if val != 0 {
+					return then(frame)
+				}
+				return otherwise(frame)
+			}, nil
+		}
+		return func(frame []float64) (float64, error) {
+			thunks := make([]funcs.Thunk, len(args))
+			for i := range args {
+				arg := args[i]
+				thunks[i] = func() (float64, error) {
+					return arg(frame)
+				}
+			}
+			return form(thunks...)
+		}, nil
+	}
+
+	f, ok := c.p.Operators[0][name]
+	if !ok {
+		return nil, errors.New("not supported function: '" + name + "'")
+	}
+	return c.call(f, name, args), nil
+}
+
+// sameForm - checks that the special form of the parser is the default one
+func sameForm(form, def funcs.SpecialFormType) bool {
+	return reflect.ValueOf(form).Pointer() == reflect.ValueOf(def).Pointer()
+}
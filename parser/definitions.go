@@ -0,0 +1,192 @@
+package parser
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/overseven/go-math-expression-parser/funcs"
+	dfuncs "github.com/overseven/go-math-expression-parser/funcs/basic"
+	"github.com/overseven/go-math-expression-parser/funcs/userfunc"
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// isDefinition - checks that the next tokens are 'name(param1, param2, ...) ='
+func isDefinition(st *tokenStream) bool {
+	if st.peek().Kind != internal.TokenTerm || st.peekAt(1).Kind != internal.TokenLParen {
+		return false
+	}
+	i := 2
+	if st.peekAt(i).Kind == internal.TokenTerm {
+		i++
+		for st.peekAt(i).Kind == internal.TokenComma && st.peekAt(i+1).Kind == internal.TokenTerm {
+			i += 2
+		}
+	}
+	return st.peekAt(i).Kind == internal.TokenRParen && st.peekAt(i+1).Kind == internal.TokenAssign
+}
+
+// parseDefinition - parse 'f(x, y) = expression' and register the function in the parser.
+// The body can use only the parameters and it can call the function recursively
+func (p *Parser) parseDefinition(st *tokenStream) error {
+	name := st.next()
+	if _, err := strconv.ParseFloat(name.Val, 64); err == nil || isKeyword(name.Val) {
+		return errors.New("incorrect function name '" + name.Val + "' at " + strconv.Itoa(name.Pos) + " position")
+	}
+	if _, ok := dfuncs.DefaultOperators[0][name.Val]; ok {
+		return errors.New("can't redefine built-in function '" + name.Val + "'")
+	}
+	// the special form would be called instead of the definition
+	if _, ok := p.SpecialForms[name.Val]; ok {
+		return errors.New("can't redefine built-in function '" + name.Val + "'")
+	}
+	if err := p.checkFunctionName(name); err != nil {
+		return err
+	}
+
+	def := &userfunc.Definition{Name: name.Val}
+	params := make(map[string]interface{})
+	for tok := st.next(); tok.Kind != internal.TokenRParen; tok = st.next() {
+		if tok.Kind != internal.TokenTerm {
+			continue
+		}
+		if _, err := strconv.ParseFloat(tok.Val, 64); err == nil || isKeyword(tok.Val) {
+			return errors.New("incorrect parameter '" + tok.Val + "' at " + strconv.Itoa(tok.Pos) + " position")
+		}
+		if _, ok := params[tok.Val]; ok {
+			return errors.New("duplicate parameter '" + tok.Val + "' of '" + def.Name + "' function")
+		}
+		if err := p.checkVariable(tok); err != nil {
+			return err
+		}
+		params[tok.Val] = struct{}{}
+		def.Params = append(def.Params, tok.Val)
+	}
+	// skip '='
+	st.next()
+
+	// register before parsing of the body to allow recursive calls
+	p.define(def)
+	start := st.peek().Pos
+	body, err := p.parseExpression(st)
+	if err != nil {
+		return err
+	}
+	def.Body = body
+	def.Source = strings.TrimSpace(string(st.source[start:st.peek().Pos]))
+
+	vars := make(map[string]interface{})
+	body.GetVarList(vars)
+	for v := range vars {
+		if _, ok := params[v]; !ok {
+			return errors.New("variable '" + v + "' is not a parameter of '" + def.Name + "' function")
+		}
+	}
+	return nil
+}
+
+func (p *Parser) define(def *userfunc.Definition) {
+	p.Definitions[def.Name] = def
+	p.Operators[0][def.Name] = func(args ...float64) (float64, error) {
+		return def.Evaluate(p, p.Definitions, p.MaxCallDepth, args...)
+	}
+}
+
+// checkArity - checks count of args of the defined function call
+func (p *Parser) checkArity(f interfaces.Function) (interfaces.Expression, error) {
+	if def, ok := p.Definitions[f.GetOperation()]; ok && len(def.Params) != len(f.GetArgs()) {
+		return nil, errors.New("incorrect count of args for '" + def.Name + "' function. Need: " +
+			strconv.Itoa(len(def.Params)) + ", but get: " + strconv.Itoa(len(f.GetArgs())))
+	}
+	return f, nil
+}
+
+// saveFunctions - copy of the functions to restore them if parsing fails
+func (p *Parser) saveFunctions() (map[string]funcs.FuncType, map[string]*userfunc.Definition) {
+	operators := make(map[string]funcs.FuncType, len(p.Operators[0]))
+	for name, f := range p.Operators[0] {
+		operators[name] = f
+	}
+	definitions := make(map[string]*userfunc.Definition, len(p.Definitions))
+	for name, def := range p.Definitions {
+		definitions[name] = def
+	}
+	return operators, definitions
+}
+
+func (p *Parser) restoreFunctions(operators map[string]funcs.FuncType, definitions map[string]*userfunc.Definition) {
+	p.Operators[0] = operators
+	p.Definitions = definitions
+}
+
+// domain - wrap the domain to evaluate the defined functions and the special forms of the parser,
+// the replaced and the user's special forms aren't supported
+func (p *Parser) domain(d interfaces.Domain) interfaces.Domain {
+	builtin := make(map[string]bool, len(p.SpecialForms))
+	for name, form := range p.SpecialForms {
+		def, ok := dfuncs.DefaultSpecialForms[name]
+		builtin[name] = ok && sameForm(form, def)
+	}
+	return userfunc.WithDefinitions(userfunc.WithSpecialForms(d, builtin), p.Definitions, p.MaxCallDepth)
+}
+
+// ExportFunctions - text of the defined functions, one definition per line
+func (p *Parser) ExportFunctions() string {
+	var names []string
+	for name := range p.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	str := ""
+	for _, name := range names {
+		str += p.Definitions[name].String() + "\n"
+	}
+	return str
+}
+
+// ImportFunctions - define functions from the text, which contains only definitions
+// separated by ';' or new lines
+func (p *Parser) ImportFunctions(text string) error {
+	st, err := p.tokenize(text)
+	if err != nil {
+		return err
+	}
+	operators, definitions := p.saveFunctions()
+
+	// register all names before parsing of the bodies, so the definitions may be in any order
+	for st.pos = 0; st.peek().Kind != internal.TokenEOF; st.next() {
+		if (st.pos == 0 || st.tokens[st.pos-1].Kind == internal.TokenSeparator) && isDefinition(st) {
+			def := &userfunc.Definition{Name: st.peek().Val}
+			for i := 2; st.peekAt(i).Kind != internal.TokenRParen; i++ {
+				if tok := st.peekAt(i); tok.Kind == internal.TokenTerm {
+					def.Params = append(def.Params, tok.Val)
+				}
+			}
+			p.define(def)
+		}
+	}
+
+	st.pos = 0
+	for err == nil {
+		for st.peek().Kind == internal.TokenSeparator {
+			st.next()
+		}
+		tok := st.peek()
+		if tok.Kind == internal.TokenEOF {
+			return nil
+		}
+		if !isDefinition(st) {
+			err = errors.New("function definition is expected at " + strconv.Itoa(tok.Pos) + " position")
+			break
+		}
+		err = p.parseDefinition(st)
+		if tok := st.peek(); err == nil && tok.Kind != internal.TokenSeparator && tok.Kind != internal.TokenEOF {
+			err = unexpected(tok)
+		}
+	}
+	p.restoreFunctions(operators, definitions)
+	return err
+}
@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// FormatOptions - spacing of the infix format
+type FormatOptions struct {
+	// Spaces - spaces around binary operators and '=', after separators of function arguments: a * (b + c)
+	Spaces bool
+	// TightLevel - operators of the priority level and lower levels are written without spaces
+	// even if Spaces is set. With TightLevel 1: a*b + c^2
+	TightLevel int
+}
+
+// DefaultFormat - spaces around all binary operators
+var DefaultFormat = FormatOptions{Spaces: true}
+
+// context of the operand, it defines how far an unary operator extends to the right
+type formatContext int
+
+const (
+	// operand of the operators of the second and higher levels: -a*b is -(a*b)
+	contextStart formatContext = iota
+	// right operand of the first level operators: 2 * -a * b is (2 * (-a)) * b
+	contextFactor
+)
+
+// Format - infix string of the expression with minimal parenthesis, which is parsed back to the same tree.
+// Numbers and arguments separators are written in the locale of the parser
+func (p *Parser) Format(exp interfaces.Expression, opts FormatOptions) string {
+	return p.format(exp, contextStart, opts)
+}
+
+// level - priority level of the binary operator
+func (p *Parser) level(op string) int {
+	levels := p.Levels()
+	for i := 1; i < len(levels); i++ {
+		if _, ok := levels[i][op]; ok {
+			return i
+		}
+	}
+	return 1
+}
+
+func (p *Parser) format(exp interfaces.Expression, ctx formatContext, opts FormatOptions) string {
+	switch e := exp.(type) {
+	case *internal.Term:
+		if isNumber(e.Val) {
+			return p.localizeNumber(e.Val)
+		}
+		return e.Val
+
+	case *internal.Node:
+		return p.formatNode(e, opts)
+
+	case *internal.Unary:
+		return p.formatUnary(e, ctx, opts)
+
+	case interfaces.Function:
+		sep := string(p.Locale.separator())
+		if opts.Spaces {
+			sep += " "
+		}
+		args := make([]string, len(e.GetArgs()))
+		for i, arg := range e.GetArgs() {
+			args[i] = p.format(arg, contextStart, opts)
+		}
+		return e.GetOperation() + "(" + strings.Join(args, sep) + ")"
+
+	case *internal.Let:
+		value := p.format(e.Value, contextStart, opts)
+		if _, ok := e.Value.(*internal.Let); ok {
+			value = "(" + value + ")"
+		}
+		return keywordLet + " " + e.Name + p.spaced("=", opts.Spaces) + value + " " + keywordIn + " " +
+			p.format(e.Body, contextStart, opts)
+
+	case *internal.Assign:
+		return e.Name + p.spaced("=", opts.Spaces) + p.format(e.Exp, contextStart, opts)
+
+	case *internal.Script:
+		sep := ";"
+		if p.Locale.separator() == ';' {
+			sep = "\n"
+		} else if opts.Spaces {
+			sep += " "
+		}
+		stmts := make([]string, len(e.Stmts))
+		for i, stmt := range e.Stmts {
+			stmts[i] = p.format(stmt, contextStart, opts)
+		}
+		return strings.Join(stmts, sep)
+	}
+	return exp.String()
+}
+
+func (p *Parser) spaced(op string, spaces bool) string {
+	if spaces {
+		return " " + op + " "
+	}
+	return op
+}
+
+// formatNode - binary operators are left-associative, the right operand of the same level is in parenthesis
+func (p *Parser) formatNode(n *internal.Node, opts FormatOptions) string {
+	level := p.level(n.Op)
+
+	left := p.format(n.LExp, contextStart, opts)
+	switch l := n.LExp.(type) {
+	case *internal.Node:
+		if p.level(l.Op) > level {
+			left = "(" + left + ")"
+		}
+	case *internal.Unary:
+		// an unary operator at the beginning of the first level operators extends to the whole chain
+		if level == 1 {
+			left = "(" + left + ")"
+		}
+	case *internal.Let, *internal.Assign, *internal.Script:
+		left = "(" + left + ")"
+	}
+
+	rightCtx := contextStart
+	if level == 1 {
+		rightCtx = contextFactor
+	}
+	right := p.format(n.RExp, rightCtx, opts)
+	switch r := n.RExp.(type) {
+	case *internal.Node:
+		if p.level(r.Op) >= level {
+			right = "(" + right + ")"
+		}
+	case *internal.Let, *internal.Assign, *internal.Script:
+		right = "(" + right + ")"
+	}
+
+	return left + p.spaced(n.Op, opts.Spaces && level > opts.TightLevel) + right
+}
+
+// formatUnary - the operand of the prefix operator is the first level chain or the factor
+func (p *Parser) formatUnary(u *internal.Unary, ctx formatContext, opts FormatOptions) string {
+	exp := p.format(u.Exp, ctx, opts)
+	if isName(u.Op) {
+		return u.Op + "(" + p.format(u.Exp, contextStart, opts) + ")"
+	}
+	switch e := u.Exp.(type) {
+	case *internal.Node:
+		if ctx == contextFactor || p.level(e.Op) > 1 {
+			exp = "(" + p.format(u.Exp, contextStart, opts) + ")"
+		} else {
+			exp = p.format(u.Exp, contextStart, opts)
+		}
+	case *internal.Let, *internal.Assign, *internal.Script:
+		exp = "(" + exp + ")"
+	}
+	// keep the operators separated: - -a
+	for _, c := range exp {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && !strings.ContainsRune("(._\"", c) {
+			exp = " " + exp
+		}
+		break
+	}
+	return u.Op + exp
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"unicode"
+
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// IdentifierGrammar - rules of variable and function names, which are checked at parse time.
+// A name starts with a letter or '_' and contains letters, digits and '_'
+type IdentifierGrammar struct {
+	// Unicode - allow Unicode letters and digits, otherwise only ASCII ones
+	Unicode bool
+	// Dots - allow dots between the parts of the name: point.x
+	Dots bool
+	// Reserved - names of constants and other words, which can't be used as variables or functions.
+	// Names of the functions are always reserved for variables
+	Reserved []string
+}
+
+func (g *IdentifierGrammar) isLetter(c rune) bool {
+	if c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') {
+		return true
+	}
+	return g.Unicode && unicode.IsLetter(c)
+}
+
+func (g *IdentifierGrammar) isDigit(c rune) bool {
+	if '0' <= c && c <= '9' {
+		return true
+	}
+	return g.Unicode && unicode.IsDigit(c)
+}
+
+// Valid - checks the syntax of the name
+func (g *IdentifierGrammar) Valid(name string) bool {
+	str := []rune(name)
+	if len(str) == 0 {
+		return false
+	}
+	start := true
+	for i, c := range str {
+		switch {
+		case g.isLetter(c):
+		case g.isDigit(c) && !start:
+		case c == '.' && g.Dots && !start && i+1 < len(str):
+			start = true
+			continue
+		default:
+			return false
+		}
+		start = false
+	}
+	return true
+}
+
+// IsReserved - checks that the name is in the list of reserved words
+func (g *IdentifierGrammar) IsReserved(name string) bool {
+	for _, word := range g.Reserved {
+		if word == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) incorrectIdentifier(tok internal.Token) error {
+	return errors.New("incorrect identifier '" + tok.Val + "' at " + strconv.Itoa(tok.Pos) + " position")
+}
+
+// checkVariable - checks the name of variable or parameter, if the identifier grammar is set
+func (p *Parser) checkVariable(tok internal.Token) error {
+	if p.Identifiers == nil {
+		return nil
+	}
+	if !p.Identifiers.Valid(tok.Val) {
+		return p.incorrectIdentifier(tok)
+	}
+	if p.Identifiers.IsReserved(tok.Val) || isKeyword(tok.Val) || p.isFunction(tok.Val) {
+		return errors.New("reserved name '" + tok.Val + "' can't be used as variable at " +
+			strconv.Itoa(tok.Pos) + " position")
+	}
+	return nil
+}
+
+// checkFunctionName - checks the name of defined function, if the identifier grammar is set
+func (p *Parser) checkFunctionName(tok internal.Token) error {
+	if p.Identifiers == nil {
+		return nil
+	}
+	if !p.Identifiers.Valid(tok.Val) {
+		return p.incorrectIdentifier(tok)
+	}
+	if p.Identifiers.IsReserved(tok.Val) {
+		return errors.New("reserved name '" + tok.Val + "' can't be used as function at " +
+			strconv.Itoa(tok.Pos) + " position")
+	}
+	return nil
+}
+
+// isNumber - checks that the term is a number literal
+func isNumber(val string) bool {
+	_, err := strconv.ParseFloat(val, 64)
+	return err == nil
+}
@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"errors"
+
+	"github.com/overseven/go-math-expression-parser/formats/compact"
+	"github.com/overseven/go-math-expression-parser/interfaces"
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// MarshalExpression - versioned JSON of the expression tree: {"version": 1, "root": {...}}.
+// Defined functions aren't part of the tree, they are exported by ExportFunctions()
+func (p *Parser) MarshalExpression(exp interfaces.Expression) ([]byte, error) {
+	return internal.MarshalDocument(exp)
+}
+
+// UnmarshalExpression - the expression tree of the versioned JSON. Operators and functions are checked against
+// the registered ones, names are checked by the Identifiers grammar or they must be single terms of the lexer,
+// if the grammar isn't set. The result becomes the parsed expression
+func (p *Parser) UnmarshalExpression(data []byte) (interfaces.Expression, error) {
+	exp, err := internal.UnmarshalDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.validate(exp); err != nil {
+		return nil, err
+	}
+	p.Expression = exp
+	return exp, nil
+}
+
+// validate - checks that the operators and the functions of the tree are registered
+func (p *Parser) validate(exp interfaces.Expression) error {
+	switch e := exp.(type) {
+	case *internal.Term:
+		if e.Val == "" || isNumber(e.Val) || internal.IsQuoted(e.Val) {
+			return nil
+		}
+		return p.validateName(e.Val)
+
+	case *internal.Node:
+		if _, ok := internal.BinaryOperatorExist(e.Op, p); !ok {
+			return errors.New("not supported binary operation: '" + e.Op + "'")
+		}
+		if err := p.validate(e.LExp); err != nil {
+			return err
+		}
+		return p.validate(e.RExp)
+
+	case *internal.Unary:
+		if _, ok := internal.UnaryOperatorExist(e.Op, p); !ok {
+			return errors.New("not supported unary operation: '" + e.Op + "'")
+		}
+		return p.validate(e.Exp)
+
+	case interfaces.Function:
+		if !p.isFunction(e.GetOperation()) {
+			return errors.New("unknown function '" + e.GetOperation() + "'")
+		}
+		if _, err := p.checkArity(e); err != nil {
+			return err
+		}
+		for _, arg := range e.GetArgs() {
+			if err := p.validate(arg); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *internal.Let:
+		if err := p.validateName(e.Name); err != nil {
+			return err
+		}
+		if err := p.validate(e.Value); err != nil {
+			return err
+		}
+		return p.validate(e.Body)
+
+	case *internal.Assign:
+		if err := p.validateName(e.Name); err != nil {
+			return err
+		}
+		return p.validate(e.Exp)
+
+	case *internal.Script:
+		for _, stmt := range e.Stmts {
+			if err := p.validate(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return errors.New("unknown node '" + exp.String() + "'")
+}
+
+// validateName - checks the variable name by the Identifiers grammar. Without the grammar the name must be one term
+// of the lexer, so the tree is parsed back from its string
+func (p *Parser) validateName(name string) error {
+	if p.Identifiers == nil {
+		st, err := p.tokenize(name)
+		if err != nil || isKeyword(name) || st.peek().Kind != internal.TokenTerm || st.peek().Val != name ||
+			st.peekAt(1).Kind != internal.TokenEOF {
+			return errors.New("incorrect identifier '" + name + "'")
+		}
+		return nil
+	}
+	if !p.Identifiers.Valid(name) {
+		return errors.New("incorrect identifier '" + name + "'")
+	}
+	if p.Identifiers.IsReserved(name) || isKeyword(name) || p.isFunction(name) {
+		return errors.New("reserved name '" + name + "' can't be used as variable")
+	}
+	return nil
+}
+
+// EncodeBinary - compact binary form of the expression tree with the version header and the checksum
+func (p *Parser) EncodeBinary(exp interfaces.Expression) ([]byte, error) {
+	return compact.Encode(exp)
+}
+
+// DecodeBinary - the expression tree of the binary form, it's checked like the result of UnmarshalExpression()
+func (p *Parser) DecodeBinary(data []byte) (interfaces.Expression, error) {
+	exp, err := compact.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.validate(exp); err != nil {
+		return nil, err
+	}
+	p.Expression = exp
+	return exp, nil
+}
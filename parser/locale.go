@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/overseven/go-math-expression-parser/internal"
+)
+
+// Locale - separators of numbers and function arguments, the zero value is the default locale: 1234.5, f(a, b)
+type Locale struct {
+	// Decimal - decimal separator, '.' if it isn't set
+	Decimal rune
+	// Separator - separator of function arguments, ',' if it isn't set
+	Separator rune
+	// Thousands - optional separator of digit groups in the integer part
+	Thousands rune
+}
+
+// EuropeanLocale - decimal comma, arguments separated by ';' and digits grouped by '.': f(1.234,5; 2)
+var EuropeanLocale = Locale{Decimal: ',', Separator: ';', Thousands: '.'}
+
+func (l Locale) decimal() rune {
+	if l.Decimal == 0 {
+		return '.'
+	}
+	return l.Decimal
+}
+
+func (l Locale) separator() rune {
+	if l.Separator == 0 {
+		return ','
+	}
+	return l.Separator
+}
+
+// validate - separators must be different and they can't be letters, digits or other punctuation
+func (l Locale) validate() error {
+	seps := []rune{l.decimal(), l.separator()}
+	if l.Thousands != 0 {
+		seps = append(seps, l.Thousands)
+	}
+	for i, c := range seps {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune("()=\"_\n", c) {
+			return errors.New("incorrect separator '" + string(c) + "' of locale")
+		}
+		for _, other := range seps[:i] {
+			if c == other {
+				return errors.New("separator '" + string(c) + "' of locale is used twice")
+			}
+		}
+	}
+	if l.decimal() == ';' {
+		return errors.New("incorrect decimal separator ';' of locale")
+	}
+	return nil
+}
+
+// apply - set the separators of the lexer
+func (l Locale) apply(lexer *internal.Lexer) {
+	lexer.Decimal = l.Decimal
+	lexer.Separator = l.Separator
+	lexer.Thousands = l.Thousands
+}
+
+// FormatNumber - string representation of the number in the locale of the parser
+func (p *Parser) FormatNumber(val float64) string {
+	if math.IsInf(val, 0) || math.IsNaN(val) || math.Abs(val) >= 1e21 || (val != 0 && math.Abs(val) < 1e-6) {
+		return p.localizeNumber(strconv.FormatFloat(val, 'g', -1, 64))
+	}
+	str := strconv.FormatFloat(val, 'f', -1, 64)
+	intPart, frac := str, ""
+	if dot := strings.IndexByte(str, '.'); dot >= 0 {
+		intPart, frac = str[:dot], string(p.Locale.decimal())+str[dot+1:]
+	}
+	if p.Locale.Thousands != 0 {
+		sign := ""
+		if strings.HasPrefix(intPart, "-") {
+			sign, intPart = "-", intPart[1:]
+		}
+		grouped := ""
+		for len(intPart) > 3 {
+			grouped = string(p.Locale.Thousands) + intPart[len(intPart)-3:] + grouped
+			intPart = intPart[:len(intPart)-3]
+		}
+		intPart = sign + intPart + grouped
+	}
+	return intPart + frac
+}
+
+// localizeNumber - replace the decimal point of the number literal
+func (p *Parser) localizeNumber(num string) string {
+	return strings.Replace(num, ".", string(p.Locale.decimal()), 1)
+}
+
+// localize - replace decimal points of the numbers and separators of the arguments in the string
+// representation of the expression, quoted strings are kept
+func (p *Parser) localize(str string) string {
+	if p.Locale == (Locale{}) {
+		return str
+	}
+	var sb strings.Builder
+	word := ""
+	flush := func() {
+		if isNumber(word) {
+			word = p.localizeNumber(word)
+		}
+		sb.WriteString(word)
+		word = ""
+	}
+	quoted := false
+	for _, c := range str {
+		switch {
+		case c == '"':
+			flush()
+			quoted = !quoted
+			sb.WriteRune(c)
+		case quoted:
+			sb.WriteRune(c)
+		case c == ',':
+			flush()
+			sb.WriteRune(p.Locale.separator())
+		case c == ' ' || c == '(' || c == ')':
+			flush()
+			sb.WriteRune(c)
+		default:
+			word += string(c)
+		}
+	}
+	flush()
+	return sb.String()
+}
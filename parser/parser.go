@@ -2,10 +2,24 @@ package parser
 
 import (
 	"errors"
+	"math"
+	"math/rand"
 	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 
+	"github.com/overseven/go-math-expression-parser/domains/dual"
+	"github.com/overseven/go-math-expression-parser/domains/interval"
+	"github.com/overseven/go-math-expression-parser/domains/uncertainty"
+	"github.com/overseven/go-math-expression-parser/domains/units"
+	"github.com/overseven/go-math-expression-parser/formats/gocode"
+	"github.com/overseven/go-math-expression-parser/formats/infix"
+	"github.com/overseven/go-math-expression-parser/formats/latex"
+	"github.com/overseven/go-math-expression-parser/formats/mathml"
+	"github.com/overseven/go-math-expression-parser/formats/rpn"
+	"github.com/overseven/go-math-expression-parser/formats/sexpr"
+	"github.com/overseven/go-math-expression-parser/formats/tree"
 	"github.com/overseven/go-math-expression-parser/funcs"
 	dfuncs "github.com/overseven/go-math-expression-parser/funcs/basic"
 	"github.com/overseven/go-math-expression-parser/funcs/userfunc"
@@ -15,13 +29,87 @@ import (
 
 // Parser - context structure, which contains user-defined function
 type Parser struct {
-	Operators  [funcs.LevelsOfPriorities]map[string]funcs.FuncType
-	Expression interfaces.Expression
+	Operators [funcs.LevelsOfPriorities]map[string]funcs.FuncType
+	// Comparisons - comparison operators, which have lower priority than the Operators
+	Comparisons map[string]funcs.FuncType
+	// SpecialForms - functions, which receive unevaluated arguments
+	SpecialForms map[string]funcs.SpecialFormType
+	// Derivatives - partial derivatives of user functions with respect to each argument
+	Derivatives map[string][]funcs.FuncType
+	// Definitions - functions defined in the expression syntax: f(x, y) = x^2 + y
+	Definitions map[string]*userfunc.Definition
+	// MaxCallDepth - limit of recursive calls of the defined functions
+	MaxCallDepth int
+	// Identifiers - grammar of names, which is checked at parse time.
+	// If it's nil, any term which isn't a number is a variable
+	Identifiers *IdentifierGrammar
+	// PercentLiterals - trailing '%' of the number means /100: 15% = 0.15, otherwise it's the modulo operator
+	PercentLiterals bool
+	// MagnitudeSuffixes - suffixes k, M, G, T of the number: 2k = 2000
+	MagnitudeSuffixes bool
+	// Symbols - symbols replaced by the text before parsing: '×' by '*', '√' by 'sqrt', 'π' by the number.
+	// NewParser sets the copy of DefaultSymbols
+	Symbols map[string]string
+	// Superscripts - superscript digits are exponents: x² = x^2, x⁻¹ = x^(-1)
+	Superscripts bool
+	// LatexTemplates - LaTeX of the user functions, #1, #2, ... are replaced by the arguments
+	LatexTemplates map[string]string
+	// Locale - separators of numbers and function arguments, it's used by the lexer and by String()
+	Locale Locale
+	// Arities - count of arguments of the functions for RPN, defined functions have the count of their parameters.
+	// NewParser sets the copy of DefaultArities
+	Arities map[string]int
+	// ImplicitMultiplication - multiply adjacent numbers, variables and parenthesised groups: 2x + 3(y - 1)
+	ImplicitMultiplication bool
+	Expression             interfaces.Expression
 }
 
+// keywords of let-bindings: let name = value in body. 'in' is the keyword only in the values of the bindings,
+// otherwise it's the name of a variable or of a unit
+const (
+	keywordLet = "let"
+	keywordIn  = "in"
+)
+
+// DefaultSymbols - Unicode math symbols and their replacements
+var DefaultSymbols = map[string]string{
+	"×": "*",
+	"·": "*",
+	"⋅": "*",
+	"÷": "/",
+	"∕": "/",
+	"−": "-",
+	"≤": "<=",
+	"≥": ">=",
+	"≠": "!=",
+	"√": "sqrt",
+	// the constant is in parenthesis, so it isn't merged with the adjacent terms
+	"π": "(" + strconv.FormatFloat(math.Pi, 'g', -1, 64) + ")",
+}
+
+// DefaultMaxCallDepth - default limit of recursive calls of the defined functions
+const DefaultMaxCallDepth = 256
+
 // NewParser - create a Parser object with default set of operators and functions
 func NewParser() *Parser {
 	p := new(Parser)
+	p.Derivatives = make(map[string][]funcs.FuncType)
+	p.SpecialForms = make(map[string]funcs.SpecialFormType)
+	for key, f := range dfuncs.DefaultSpecialForms {
+		p.SpecialForms[key] = f
+	}
+	p.Definitions = make(map[string]*userfunc.Definition)
+	p.MaxCallDepth = DefaultMaxCallDepth
+	p.Symbols = make(map[string]string, len(DefaultSymbols))
+	for key, val := range DefaultSymbols {
+		p.Symbols[key] = val
+	}
+	p.Superscripts = true
+	p.LatexTemplates = make(map[string]string)
+	p.Arities = make(map[string]int, len(dfuncs.DefaultArities))
+	for key, n := range dfuncs.DefaultArities {
+		p.Arities[key] = n
+	}
 
 	for i := range p.Operators {
 		p.Operators[i] = make(map[string]funcs.FuncType)
@@ -29,176 +117,773 @@ func NewParser() *Parser {
 			p.Operators[i][key] = f
 		}
 	}
+	p.Comparisons = make(map[string]funcs.FuncType, len(dfuncs.DefaultComparisons))
+	for key, f := range dfuncs.DefaultComparisons {
+		p.Comparisons[key] = f
+	}
 
 	return p
 }
 
 // AddFunction - add user's function and it string representation
 func (p *Parser) AddFunction(f funcs.FuncType, s string) {
+	p.AddFunctionWithDerivatives(f, s)
+}
+
+// AddFunctionWithDerivatives - add user's function with partial derivatives with respect to each argument,
+// they are used by EvaluateGradient
+func (p *Parser) AddFunctionWithDerivatives(f funcs.FuncType, s string, derivatives ...funcs.FuncType) {
 	p.Operators[0][s] = f
+	if len(derivatives) > 0 {
+		p.Derivatives[s] = derivatives
+	} else {
+		delete(p.Derivatives, s)
+	}
+}
+
+// AddLatexTemplate - set LaTeX of the function for Latex(), #1, #2, ... are replaced by the arguments:
+// parser.AddLatexTemplate("gamma", "\\Gamma\\left(#1\\right)")
+func (p *Parser) AddLatexTemplate(name, template string) {
+	p.LatexTemplates[name] = template
+}
+
+// Latex - LaTeX of the expression: '/' is a fraction, '^' is a superscript, Greek names are symbols
+func (p *Parser) Latex(exp interfaces.Expression) string {
+	return latex.NewRenderer(p.Levels(), p.LatexTemplates).Render(exp)
+}
+
+// TreeASCII - indented tree of the expression with the types of nodes, the values of nodes are printed if vars isn't nil
+func (p *Parser) TreeASCII(exp interfaces.Expression, vars map[string]float64) string {
+	return p.treeExporter(vars).ASCII(exp)
+}
+
+// TreeDOT - Graphviz graph of the expression, the values of nodes are printed if vars isn't nil
+func (p *Parser) TreeDOT(exp interfaces.Expression, vars map[string]float64) string {
+	return p.treeExporter(vars).DOT(exp)
+}
+
+func (p *Parser) treeExporter(vars map[string]float64) *tree.Exporter {
+	if vars == nil {
+		return &tree.Exporter{}
+	}
+	return &tree.Exporter{Parser: p, Vars: vars}
+}
+
+// GenerateGo - gofmt'd Go file of the package with the function of the expression:
+// func Total(price, qty float64) (float64, error). The variables are parameters in GetVarList order
+func (p *Parser) GenerateGo(exp interfaces.Expression, pkg, name string) ([]byte, error) {
+	return gocode.Generate(exp, gocode.Options{Package: pkg, Func: name, Params: GetVarList(exp),
+		Source: p.Format(exp, DefaultFormat)})
+}
+
+// SExpr - canonical S-expression of the tree, ParseSExpr() reads it back: (+ a (f (b, 2)))
+func (p *Parser) SExpr(exp interfaces.Expression) string {
+	return sexpr.Write(exp)
+}
+
+// ParseSExpr - the tree of the S-expression, which is written by SExpr() or by String() of the tree.
+// It's used to replay the logged trees
+func (p *Parser) ParseSExpr(src string) (interfaces.Expression, error) {
+	exp, err := sexpr.Read(src)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.validate(exp); err != nil {
+		return nil, err
+	}
+	p.Expression = exp
+	return exp, nil
+}
+
+// ToRPN - tokens of the expression in reverse Polish notation: (a + b) * 2 is [a b + 2 *].
+// Unary operators have the prefix "u", functions without arity are written with the count of arguments: max@3
+func (p *Parser) ToRPN(exp interfaces.Expression) ([]string, error) {
+	return rpn.Write(exp, p.arity)
+}
+
+// ParseRPN - parse tokens in reverse Polish notation separated by spaces to the same tree as Parse()
+func (p *Parser) ParseRPN(src string) (interfaces.Expression, error) {
+	tokens, err := rpn.Split(src)
+	if err != nil {
+		return nil, err
+	}
+	exp, err := rpn.Read(tokens, rpn.Grammar{Functions: p.Levels(), SpecialForms: p.SpecialForms, Arity: p.arity,
+		Variable: p.isVariable})
+	if err != nil {
+		return nil, err
+	}
+	if err := p.validate(exp); err != nil {
+		return nil, err
+	}
+	p.Expression = exp
+	return exp, nil
+}
+
+// isVariable - checks the name of variable, which isn't split by the lexer: the identifier grammar, if it's set,
+// otherwise letters, digits, '_' and '.'
+func (p *Parser) isVariable(name string) bool {
+	if p.Identifiers != nil {
+		return p.Identifiers.Valid(name)
+	}
+	return infix.IsIdentifier(name)
+}
+
+// arity - count of arguments of the defined or registered function
+func (p *Parser) arity(name string) (int, bool) {
+	if def, ok := p.Definitions[name]; ok {
+		return len(def.Params), true
+	}
+	n, ok := p.Arities[name]
+	return n, ok
+}
+
+// ParseLatex - parse LaTeX formula like '\\frac{a}{b} + \\sqrt{x^{2}}' to the same tree as Parse().
+// Single letters are variables, adjacent operands are multiplied and the registered functions are calls
+func (p *Parser) ParseLatex(src string) (interfaces.Expression, error) {
+	str, err := latex.ToInfix(src, p.isFunction)
+	if err != nil {
+		return nil, err
+	}
+	return p.parseFormula(str)
+}
+
+// ParseMathML - parse presentation MathML to the same tree as Parse(), the registered functions are calls
+func (p *Parser) ParseMathML(src string) (interfaces.Expression, error) {
+	str, err := mathml.ToInfix(src, p.isFunction)
+	if err != nil {
+		return nil, err
+	}
+	return p.parseFormula(str)
+}
+
+// parseFormula - parse the converted formula as the single expression,
+// definitions, assignments and statements aren't allowed. The readers write numbers and arguments
+// in the default locale, so the locale of the parser isn't used
+func (p *Parser) parseFormula(str string) (interfaces.Expression, error) {
+	st, err := p.tokenizeLocale(str, Locale{})
+	if err != nil {
+		return nil, err
+	}
+	res, err := p.parseExpression(st)
+	if err != nil {
+		return nil, err
+	}
+	if tok := st.peek(); tok.Kind != internal.TokenEOF {
+		return nil, unexpected(tok)
+	}
+	p.Expression = res
+	return res, nil
 }
 
 func (p *Parser) GetFunctions() [funcs.LevelsOfPriorities]map[string]funcs.FuncType {
 	return p.Operators
 }
 
-// String - string representation of expression
+func (p *Parser) GetComparisons() map[string]funcs.FuncType {
+	return p.Comparisons
+}
+
+// Levels - operators of all priorities, the comparisons are the lowest level
+func (p *Parser) Levels() [funcs.LevelsOfOperators]map[string]funcs.FuncType {
+	return internal.Operators(p)
+}
+
+// AddSpecialForm - add user's special form and it string representation.
+// The special form receives unevaluated arguments and evaluates only needed ones
+func (p *Parser) AddSpecialForm(f funcs.SpecialFormType, s string) {
+	p.SpecialForms[s] = f
+}
+
+func (p *Parser) GetSpecialForms() map[string]funcs.SpecialFormType {
+	return p.SpecialForms
+}
+
+// String - string representation of expression, numbers and arguments separators are written in the locale
 func (p *Parser) String() string {
-	return p.Expression.String()
+	return p.localize(p.Expression.String())
 }
 
-// Parse - parsing a string format math expression, return Exp tree
+// Parse - parsing a string format math expression, return Exp tree.
+// Statements separated by ';' or new lines are parsed to a script, where 'name = expression'
+// assigns a variable for the next statements and 'f(x, y) = expression' defines a function
 func (p *Parser) Parse(str string) (interfaces.Expression, error) {
-	if indx, ok := internal.ParenthesisIsCorrect(str); !ok {
-		return nil, errors.New("incorrect parenthesis at " + strconv.Itoa(indx) + " position")
+	st, err := p.tokenize(str)
+	if err != nil {
+		return nil, err
 	}
-	str = internal.PrepareString(str)
-	//fmt.Println("Remove spaces: '" + str + "'")
-	res, err := p.parseStr([]rune(str))
+	operators, definitions := p.saveFunctions()
+	res, err := p.parseScript(st)
 	if err != nil {
+		p.restoreFunctions(operators, definitions)
 		return nil, err
 	}
 	p.Expression = res
 	return res, nil
 }
 
+func (p *Parser) tokenize(str string) (*tokenStream, error) {
+	return p.tokenizeLocale(str, p.Locale)
+}
+
+// tokenizeLocale - tokenize with the separators of the locale instead of the parser's one
+func (p *Parser) tokenizeLocale(str string, locale Locale) (*tokenStream, error) {
+	if indx, ok := internal.ParenthesisIsCorrect(str); !ok {
+		return nil, errors.New("incorrect parenthesis at " + strconv.Itoa(indx) + " position")
+	}
+	if err := locale.validate(); err != nil {
+		return nil, err
+	}
+	lexer := internal.NewLexer(p.symbols())
+	locale.apply(lexer)
+	lexer.Percent = p.PercentLiterals
+	lexer.Suffixes = p.MagnitudeSuffixes
+	lexer.Superscripts = p.Superscripts
+	lexer.SetAliases(p.Symbols)
+	tokens, err := lexer.Tokenize(str)
+	if err != nil {
+		return nil, err
+	}
+	if p.ImplicitMultiplication {
+		var split []internal.Token
+		for _, tok := range tokens {
+			split = append(split, internal.SplitNumber(tok)...)
+		}
+		tokens = split
+	}
+	return &tokenStream{tokens: tokens, source: []rune(str)}, nil
+}
+
 // Evaluate - execute expression and return result
 func (p *Parser) Evaluate(vars map[string]float64) (float64, error) {
 	result, err := p.Expression.Evaluate(vars, p)
 	return result, err
 }
 
-func (p *Parser) parseFunc(str []rune) (f interfaces.Function, isFunc bool, err error) {
-	ind := strings.IndexRune(string(str), '(')
-	var args [][]rune
-	if ind <= 0 {
-		return &userfunc.Func{}, false, nil
+// EvaluateScript - execute statements and return the value of the last one with the final variables environment
+func (p *Parser) EvaluateScript(vars map[string]float64) (float64, map[string]float64, error) {
+	if script, ok := p.Expression.(*internal.Script); ok {
+		return script.Run(vars, p)
+	}
+	result, err := p.Evaluate(vars)
+	if err != nil {
+		return 0.0, nil, err
 	}
-	f = new(userfunc.Func)
-	f.SetOperation(string(str[:ind]))
-	if _, ok := p.Operators[0][f.GetOperation()]; !ok {
-		return &userfunc.Func{}, false, errors.New("function '" + f.GetOperation() + "' is not supported")
+	env := make(map[string]float64, len(vars))
+	for name, val := range vars {
+		env[name] = val
 	}
+	return result, env, nil
+}
 
-	level := 0
+// EvaluateUnits - execute expression over the quantities with units of measure
+func (p *Parser) EvaluateUnits(vars map[string]units.Quantity) (units.Quantity, error) {
+	values := make(map[string]interfaces.Value, len(vars))
+	for name, q := range vars {
+		values[name] = q
+	}
+	result, err := p.Expression.EvaluateIn(values, p.domain(units.NewDomain(p.Levels())))
+	if err != nil {
+		return units.Quantity{}, err
+	}
+	return result.(units.Quantity), nil
+}
 
-	start := ind + 1
+// EvaluateInterval - execute expression over the ranges of variables, return guaranteed bounds of the result
+func (p *Parser) EvaluateInterval(vars map[string]interval.Interval) (interval.Interval, error) {
+	values := make(map[string]interfaces.Value, len(vars))
+	for name, x := range vars {
+		values[name] = x
+	}
+	result, err := p.Expression.EvaluateIn(values, p.domain(interval.NewDomain(p.Levels())))
+	if err != nil {
+		return interval.Interval{}, err
+	}
+	return result.(interval.Interval), nil
+}
 
-	for i := start; i <= len(str)-1; i++ {
-		c := str[i]
-		switch c {
-		case '(':
-			level++
-			//end--
-			continue
+// EvaluateGradient - execute expression and return the result with its gradient
+// with respect to the wrt variables, which must be defined in vars
+func (p *Parser) EvaluateGradient(vars map[string]float64, wrt ...string) (float64, map[string]float64, error) {
+	values := make(map[string]interfaces.Value, len(vars))
+	for name, val := range vars {
+		values[name] = dual.Const(val, len(wrt))
+	}
+	for i, name := range wrt {
+		val, ok := vars[name]
+		if !ok {
+			return 0, nil, errors.New("value '" + name + " not found in map")
+		}
+		values[name] = dual.Variable(val, i, len(wrt))
+	}
+	result, err := p.Expression.EvaluateIn(values, p.domain(dual.NewDomain(len(wrt), p.Levels(), p.Derivatives)))
+	if err != nil {
+		return 0, nil, err
+	}
+	res := result.(dual.Dual)
+	grad := make(map[string]float64, len(wrt))
+	for i, name := range wrt {
+		grad[name] = res.Grad[i]
+	}
+	return res.Val, grad, nil
+}
 
-		case ')':
-			level--
-			if i != len(str)-1 {
-				continue
-			}
-			fallthrough
+// EvaluateUncertainty - execute expression over the measured values with the first-order propagation
+// of uncertainty. corr is an optional correlation matrix of the variables
+func (p *Parser) EvaluateUncertainty(vars map[string]uncertainty.Measurement, corr *uncertainty.Correlation) (uncertainty.Measurement, error) {
+	values := make(map[string]float64, len(vars))
+	for name, m := range vars {
+		values[name] = m.Value
+	}
+	val, grad, err := p.EvaluateGradient(values, uncertainty.Uncertain(vars)...)
+	if err != nil {
+		return uncertainty.Measurement{}, err
+	}
+	return uncertainty.Linear(val, grad, vars, corr)
+}
 
-		default:
-			if level > 0 {
-				continue
+// EvaluateMonteCarlo - execute expression for the samples of normally distributed measured values,
+// return the mean and the standard deviation of the results
+func (p *Parser) EvaluateMonteCarlo(vars map[string]uncertainty.Measurement, corr *uncertainty.Correlation,
+	samples int, seed int64) (uncertainty.Measurement, error) {
+	eval := func(values map[string]float64) (float64, error) {
+		return p.Expression.Evaluate(values, p)
+	}
+	return uncertainty.MonteCarlo(eval, vars, corr, samples, rand.New(rand.NewSource(seed)))
+}
 
-			} else if c == ',' || i == len(str)-1 {
-				//fmt.Println("start:", start, "i:", i)
-				args = append(args, str[start:i])
-				start = i + 1
+// tokenStream - tokens of the parsed string
+type tokenStream struct {
+	tokens []internal.Token
+	source []rune
+	pos    int
+	// lets - count of the let-bindings, which values are parsed now
+	lets int
+}
+
+// isKeyword - 'let' is always the keyword, 'in' only ends the values of the let-bindings
+func (st *tokenStream) isKeyword(val string) bool {
+	return isKeyword(val) || val == keywordIn && st.lets > 0
+}
 
+func (st *tokenStream) peek() internal.Token {
+	return st.tokens[st.pos]
+}
+
+func (st *tokenStream) peekAt(offset int) internal.Token {
+	if st.pos+offset >= len(st.tokens) {
+		return st.tokens[len(st.tokens)-1]
+	}
+	return st.tokens[st.pos+offset]
+}
+
+func (st *tokenStream) next() internal.Token {
+	tok := st.tokens[st.pos]
+	if tok.Kind != internal.TokenEOF {
+		st.pos++
+	}
+	return tok
+}
+
+func unexpected(tok internal.Token) error {
+	if tok.Kind == internal.TokenEOF {
+		return errors.New("unexpected end of expression")
+	}
+	return errors.New("unexpected '" + tok.Val + "' at " + strconv.Itoa(tok.Pos) + " position")
+}
+
+// symbols - all operators and functions names
+func (p *Parser) symbols() []string {
+	var symbols []string
+	for _, ops := range p.Levels() {
+		for op := range ops {
+			symbols = append(symbols, op)
+		}
+	}
+	return symbols
+}
+
+// parseScript - statements separated by ';' or new lines
+func (p *Parser) parseScript(st *tokenStream) (interfaces.Expression, error) {
+	var stmts []interfaces.Expression
+	for {
+		for st.peek().Kind == internal.TokenSeparator {
+			st.next()
+		}
+		if st.peek().Kind == internal.TokenEOF {
+			break
+		}
+		stmt, err := p.parseStatement(st)
+		if err != nil {
+			return nil, err
+		}
+		if stmt != nil {
+			stmts = append(stmts, stmt)
+		}
+		if tok := st.peek(); tok.Kind != internal.TokenSeparator && tok.Kind != internal.TokenEOF {
+			return nil, unexpected(tok)
+		}
+	}
+
+	if len(stmts) == 0 {
+		return &internal.Term{Val: "0"}, nil
+	}
+	if _, isAssign := stmts[0].(*internal.Assign); len(stmts) == 1 && !isAssign {
+		return stmts[0], nil
+	}
+	return &internal.Script{Stmts: stmts}, nil
+}
+
+// parseStatement - function definition, assignment 'name = expression' or expression.
+// Definitions are registered in the parser and they aren't included to the result
+func (p *Parser) parseStatement(st *tokenStream) (interfaces.Expression, error) {
+	if isDefinition(st) {
+		return nil, p.parseDefinition(st)
+	}
+	if st.peek().Kind == internal.TokenTerm && st.peekAt(1).Kind == internal.TokenAssign {
+		name := st.next()
+		st.next()
+		if _, err := strconv.ParseFloat(name.Val, 64); err == nil {
+			return nil, errors.New("can't assign to number '" + name.Val + "' at " + strconv.Itoa(name.Pos) + " position")
+		}
+		if isKeyword(name.Val) {
+			return nil, unexpected(name)
+		}
+		if err := p.checkVariable(name); err != nil {
+			return nil, err
+		}
+		exp, err := p.parseExpression(st)
+		if err != nil {
+			return nil, err
+		}
+		return &internal.Assign{Name: name.Val, Exp: exp}, nil
+	}
+	return p.parseExpression(st)
+}
+
+func (p *Parser) parseExpression(st *tokenStream) (interfaces.Expression, error) {
+	return p.parseBinary(st, funcs.LevelsOfOperators-1)
+}
+
+// prefix - checks that the next token is an unary operator
+func (p *Parser) prefix(st *tokenStream) bool {
+	tok := st.peek()
+	if tok.Kind != internal.TokenOperator {
+		return false
+	}
+	_, ok := p.Operators[0][tok.Val]
+	return ok
+}
+
+// parseBinary - left-associative binary operators of the priority level.
+// Unary operators have lower priority than the operators of the first level: -2^2 = -(2^2)
+func (p *Parser) parseBinary(st *tokenStream, level int) (interfaces.Expression, error) {
+	operand := func() (interfaces.Expression, error) {
+		if level == 1 {
+			return p.parseFactor(st)
+		}
+		return p.parseBinary(st, level-1)
+	}
+	if level == 1 && p.prefix(st) && !isName(st.peek().Val) {
+		op := st.next()
+		exp, err := p.parseBinary(st, level)
+		if err != nil {
+			return nil, err
+		}
+		return &internal.Unary{Op: op.Val, Exp: exp}, nil
+	}
+
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := st.peek()
+		if level == 1 && p.adjacent(st) {
+			right, err := p.parseImplicit(st)
+			if err != nil {
+				return nil, err
 			}
+			left = &internal.Node{Op: "*", LExp: left, RExp: right}
+			continue
+		}
+		if tok.Kind != internal.TokenOperator {
+			return left, nil
+		}
+		if _, ok := p.Levels()[level][tok.Val]; !ok {
+			return left, nil
 		}
+		st.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &internal.Node{Op: tok.Val, LExp: left, RExp: right}
 	}
+}
 
-	// fmt.Println("Func " + f.Op + " args:")
-	// for i, elem := range args {
-	// 	fmt.Println(strconv.Itoa(i) + ".   '" + string(elem) + "'")
-	// }
-	// fmt.Println("End func " + f.Op + " args.")
+// adjacent - checks that the next token starts an operand of the implicit multiplication
+func (p *Parser) adjacent(st *tokenStream) bool {
+	if !p.ImplicitMultiplication {
+		return false
+	}
+	tok := st.peek()
+	return tok.Kind == internal.TokenLParen || (tok.Kind == internal.TokenTerm && !st.isKeyword(tok.Val)) ||
+		(tok.Kind == internal.TokenOperator && isName(tok.Val))
+}
 
-	for _, elem := range args {
-		arg, err := p.parseStr(elem)
+// parseImplicit - right operand of the implicit multiplication. It binds tighter than '^': 2x^2 = 2*(x^2)
+func (p *Parser) parseImplicit(st *tokenStream) (interfaces.Expression, error) {
+	exp, err := p.parseFactor(st)
+	if err != nil {
+		return nil, err
+	}
+	for tok := st.peek(); tok.Kind == internal.TokenOperator && tok.Val == "^"; tok = st.peek() {
+		st.next()
+		right, err := p.parseFactor(st)
 		if err != nil {
-			return f, true, err
+			return nil, err
 		}
-		f.SetArgs(append(f.GetArgs(), arg))
+		exp = &internal.Node{Op: tok.Val, LExp: exp, RExp: right}
 	}
+	return exp, nil
+}
 
-	return f, true, nil
+// isFunction - checks that the name is a function or a special form
+func (p *Parser) isFunction(name string) bool {
+	_, isFunc := p.Operators[0][name]
+	_, isSpecialForm := p.SpecialForms[name]
+	return isFunc || isSpecialForm
 }
 
-func (p *Parser) parseStr(str []rune) (interfaces.Expression, error) {
-	if len(str) == 0 {
-		return &internal.Term{Val: "0"}, nil
+// parseFactor - operand of the first level operators with optional unary operators
+func (p *Parser) parseFactor(st *tokenStream) (interfaces.Expression, error) {
+	if p.prefix(st) {
+		op := st.next()
+		exp, err := p.parseFactor(st)
+		if err != nil {
+			return nil, err
+		}
+		if isName(op.Val) {
+			// function written as an unary operator: √x = sqrt(x)
+			f := new(userfunc.Func)
+			f.SetOperation(op.Val)
+			f.SetArgs([]interfaces.Expression{exp})
+			return p.checkArity(f)
+		}
+		return &internal.Unary{Op: op.Val, Exp: exp}, nil
+	}
+	exp, err := p.parsePrimary(st)
+	if err != nil {
+		return nil, err
 	}
-	level := 0
+	// superscript exponents bind tighter than any operator: 2*x² = 2*(x^2)
+	for tok := st.peek(); tok.Kind == internal.TokenSuperscript; tok = st.peek() {
+		st.next()
+		var power interfaces.Expression = &internal.Term{Val: strings.TrimPrefix(tok.Val, "-")}
+		if strings.HasPrefix(tok.Val, "-") {
+			power = &internal.Unary{Op: "-", Exp: power}
+		}
+		exp = &internal.Node{Op: "^", LExp: exp, RExp: power}
+	}
+	return exp, nil
+}
+
+// isName - checks that the operator is a function name
+func isName(op string) bool {
+	for _, c := range op {
+		return unicode.IsLetter(c) || c == '_'
+	}
+	return false
+}
+
+// parsePrimary - term, string, function call or expression in parenthesis
+func (p *Parser) parsePrimary(st *tokenStream) (interfaces.Expression, error) {
+	tok := st.next()
+	switch tok.Kind {
+	case internal.TokenLParen:
+		if st.peek().Kind == internal.TokenRParen {
+			return nil, errors.New("empty parenthesis at " + strconv.Itoa(tok.Pos) + " position")
+		}
+		exp, err := p.parseExpression(st)
+		if err != nil {
+			return nil, err
+		}
+		if closing := st.next(); closing.Kind != internal.TokenRParen {
+			return nil, unexpected(closing)
+		}
+		return exp, nil
+
+	case internal.TokenString:
+		return &internal.Term{Val: tok.Val}, nil
 
-	for priorityLevel := 2; priorityLevel >= 1; priorityLevel-- {
-		for i := len(str) - 1; i >= 0; i-- {
-			c := str[i]
-			if c == ')' {
-				level++
-				continue
+	case internal.TokenTerm:
+		if tok.Val == keywordLet {
+			return p.parseLet(st)
+		}
+		if st.isKeyword(tok.Val) {
+			return nil, unexpected(tok)
+		}
+		if p.ImplicitMultiplication {
+			// name followed by '(' is a call only if the function is registered, otherwise it's a multiplication
+			if p.isFunction(tok.Val) {
+				if st.peek().Kind != internal.TokenLParen {
+					return nil, errors.New("function '" + tok.Val + "' needs arguments in parenthesis at " +
+						strconv.Itoa(tok.Pos) + " position")
+				}
+				return p.parseFunc(st, tok)
 			}
-			if c == '(' {
-				level--
-				continue
+			return p.parseTerm(tok)
+		}
+		if st.peek().Kind == internal.TokenLParen {
+			return p.parseFunc(st, tok)
+		}
+		if p.Identifiers != nil {
+			return p.parseTerm(tok)
+		}
+		// spaces inside a term are ignored: '5 m' is the same as '5m'
+		val := tok.Val
+		for next := st.peek(); next.Kind == internal.TokenTerm && !st.isKeyword(next.Val) &&
+			st.peekAt(1).Kind != internal.TokenLParen; next = st.peek() {
+			val += st.next().Val
+		}
+		if isQuantity(val) {
+			val = parseUnit(st, val)
+		}
+		return &internal.Term{Val: val}, nil
+	}
+	return nil, unexpected(tok)
+}
+
+// isQuantity - checks that the term is a number followed by a known unit: '5km', '9.81m/s^2'
+func isQuantity(val string) bool {
+	if val == "" || isNumber(val) || !unicode.IsDigit(rune(val[0])) && val[0] != '.' {
+		return false
+	}
+	_, err := units.Parse(val)
+	return err == nil
+}
+
+// parseUnit - the rest of the unit of the quantity: powers and units after '*' and '/'.
+// The unit binds tighter than any operator: '4 m^2' is 4 (m^2), '9.81 m/s^2' is 9.81 (m/s^2)
+func parseUnit(st *tokenStream, val string) string {
+	for {
+		var rest string
+		count := 0
+		switch tok := st.peek(); {
+		case tok.Kind == internal.TokenSuperscript:
+			rest, count = "^"+tok.Val, 1
+		case tok.Kind == internal.TokenOperator && tok.Val == "^":
+			rest, count = "^", 2
+			power := st.peekAt(1)
+			if power.Kind == internal.TokenOperator && power.Val == "-" {
+				rest, count, power = "^-", 3, st.peekAt(2)
 			}
-			if level > 0 {
-				continue
+			if power.Kind != internal.TokenTerm {
+				return val
 			}
-			if _, ok := p.GetFunctions()[priorityLevel][string(c)]; ok {
-				if i > 0 {
-					left := str[0:i]
-					right := str[i+1:]
-					resL, err := p.parseStr(left)
-					if err != nil {
-						return nil, err
-					}
-					resR, err := p.parseStr(right)
-					if err != nil {
-						return nil, err
-					}
-					return &internal.Node{Op: string(c), LExp: resL, RExp: resR}, nil
-				}
-				right := str[i+1:]
-				resR, err := p.parseStr(right)
-				if err != nil {
-					return nil, err
-				}
-				return &internal.Unary{Op: string(c), Exp: resR}, nil
+			rest += power.Val
+		case tok.Kind == internal.TokenOperator && (tok.Val == "*" || tok.Val == "/"):
+			sym := st.peekAt(1)
+			if sym.Kind != internal.TokenTerm || !isName(sym.Val) || st.peekAt(2).Kind == internal.TokenLParen ||
+				st.isKeyword(sym.Val) {
+				return val
 			}
+			rest, count = tok.Val+sym.Val, 2
+		default:
+			return val
+		}
+		if !isQuantity(val + rest) {
+			return val
+		}
+		val += rest
+		for ; count > 0; count-- {
+			st.next()
+		}
+	}
+}
+
+// parseTerm - number or variable, the name of variable is checked by the identifier grammar
+func (p *Parser) parseTerm(tok internal.Token) (interfaces.Expression, error) {
+	if !isNumber(tok.Val) {
+		if err := p.checkVariable(tok); err != nil {
+			return nil, err
 		}
 	}
+	return &internal.Term{Val: tok.Val}, nil
+}
+
+// isKeyword - the name can't be used for variables and functions
+func isKeyword(val string) bool {
+	return val == keywordLet
+}
+
+// parseLet - 'let a = 1, b = a + 1 in body' after the 'let' keyword.
+// Each binding can use the previous ones, the body extends as far right as possible
+func (p *Parser) parseLet(st *tokenStream) (interfaces.Expression, error) {
+	name := st.next()
+	if name.Kind != internal.TokenTerm || isKeyword(name.Val) || name.Val == keywordIn {
+		return nil, unexpected(name)
+	}
+	if _, err := strconv.ParseFloat(name.Val, 64); err == nil {
+		return nil, errors.New("can't bind number '" + name.Val + "' at " + strconv.Itoa(name.Pos) + " position")
+	}
+	if err := p.checkVariable(name); err != nil {
+		return nil, err
+	}
+	if tok := st.next(); tok.Kind != internal.TokenAssign {
+		return nil, unexpected(tok)
+	}
+	st.lets++
+	value, err := p.parseExpression(st)
+	st.lets--
+	if err != nil {
+		return nil, err
+	}
 
-	// parse func
-	if f, isFunc, err := p.parseFunc(str); err != nil {
+	var body interfaces.Expression
+	switch tok := st.next(); {
+	case tok.Kind == internal.TokenComma:
+		body, err = p.parseLet(st)
+	case tok.Kind == internal.TokenTerm && tok.Val == keywordIn:
+		body, err = p.parseExpression(st)
+	default:
+		return nil, unexpected(tok)
+	}
+	if err != nil {
 		return nil, err
-	} else if isFunc {
-		return f.(interfaces.Expression), nil
 	}
+	return &internal.Let{Name: name.Val, Value: value, Body: body}, nil
+}
 
-	if str[0] == '(' {
-		for i, c := range str {
-			if c == '(' {
-				level++
-				continue
-			}
-			if c == ')' {
-				level--
-				if level == 0 {
-					exp := str[1:i]
-					return p.parseStr(exp)
-				}
-				continue
-			}
+// parseFunc - function call with a comma-separated list of arguments
+func (p *Parser) parseFunc(st *tokenStream, name internal.Token) (interfaces.Expression, error) {
+	f := new(userfunc.Func)
+	f.SetOperation(name.Val)
+	_, isFunc := p.Operators[0][f.GetOperation()]
+	_, isSpecialForm := p.SpecialForms[f.GetOperation()]
+	if !isFunc && !isSpecialForm {
+		return nil, errors.New("function '" + f.GetOperation() + "' is not supported")
+	}
+	st.next()
+	if st.peek().Kind == internal.TokenRParen {
+		st.next()
+		return p.checkArity(f)
+	}
+	for {
+		arg, err := p.parseExpression(st)
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		return &internal.Term{Val: string(str)}, nil
+		f.SetArgs(append(f.GetArgs(), arg))
+		tok := st.next()
+		switch tok.Kind {
+		case internal.TokenComma:
+			continue
+		case internal.TokenRParen:
+			return p.checkArity(f)
+		}
+		return nil, unexpected(tok)
 	}
-	return nil, errors.New("unknow internal error")
 }
 
 // GetVarList - return list of variables which are used in the expression
@@ -6,8 +6,11 @@ import (
 	"math"
 	"sort"
 	"strconv"
+	"sync"
 	"testing"
 
+	"github.com/overseven/go-math-expression-parser/domains/interval"
+	"github.com/overseven/go-math-expression-parser/funcs"
 )
 
 const float64EqualityThreshold = 1e-9
@@ -232,4 +235,997 @@ func TestParseFunc(t *testing.T){
 	//_, isFunc, err := p.parseFunc([]rune("foo(a+b)"))
 	// TODO: finish
 
-}
\ No newline at end of file
+}
+
+func TestParseScript(t *testing.T) {
+	type TestVars map[string]float64
+	type TestData struct {
+		input  string
+		vars   TestVars
+		output float64
+		env    TestVars
+	}
+
+	data := []TestData{
+		{"tax = price * 0.2; total = price + tax; total * qty", TestVars{"price": 10, "qty": 3}, 36,
+			TestVars{"price": 10, "qty": 3, "tax": 2, "total": 12}},
+		{"a = 2\nb = a ^ 3\n\nb - a\n", TestVars{}, 6, TestVars{"a": 2, "b": 8}},
+		{"x = x + 1; x = x * 10", TestVars{"x": 1}, 20, TestVars{"x": 20}},
+		{"sqrt(\n16\n)", TestVars{}, 4, TestVars{}},
+		{"1; 2;", TestVars{}, 2, TestVars{}},
+	}
+
+	p := NewParser()
+	for _, d := range data {
+		_, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+		}
+		res, env, err := p.EvaluateScript(d.vars)
+		if err != nil {
+			t.Error(err)
+		}
+		if !fuzzyEqual(res, d.output) {
+			t.Error("incorrect result, need: " + fmt.Sprintf("%f", d.output) + ", but get: " + fmt.Sprintf("%f", res))
+		}
+		if len(env) != len(d.env) {
+			t.Error("incorrect environment size of '" + d.input + "': " + strconv.Itoa(len(env)))
+		}
+		for name, val := range d.env {
+			if !fuzzyEqual(env[name], val) {
+				t.Error("incorrect value of '" + name + "': " + fmt.Sprintf("%f", env[name]))
+			}
+		}
+		// Evaluate returns the same result
+		res, err = p.Evaluate(d.vars)
+		if err != nil || !fuzzyEqual(res, d.output) {
+			t.Error("incorrect Evaluate result of '" + d.input + "'")
+		}
+	}
+
+	exp, _ := p.Parse("tax = price * rate; total = price + tax; total * qty")
+	vars := GetVarList(exp)
+	if len(vars) != 3 || vars[0] != "price" || vars[1] != "qty" || vars[2] != "rate" {
+		t.Error("incorrect list of external variables: " + fmt.Sprint(vars))
+	}
+
+	for _, input := range []string{"1 = 2", "a = ", "a = 1 2 = 3", "x = (1; 2)", "2 * * 3", "foo(1 2)"} {
+		if _, err := p.Parse(input); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+}
+
+func TestDefinitions(t *testing.T) {
+	type TestVars map[string]float64
+	type TestData struct {
+		input  string
+		vars   TestVars
+		output float64
+	}
+
+	p := NewParser()
+	data := []TestData{
+		{"f(x, y) = x^2 + y\nf(3, 1)", TestVars{}, 10},
+		{"f(a, 2) * 2", TestVars{"a": 2}, 12},
+		{"g(x) = f(x, x) / 2; g(4) + f(1, 1)", TestVars{}, 12},
+		{"one() = 1; one() + x", TestVars{"x": 1}, 2},
+		{"f(y, x) = y - x; f(x, 1)", TestVars{"x": 5}, 4},
+	}
+	for _, d := range data {
+		_, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+		}
+		res, err := p.Evaluate(d.vars)
+		if err != nil {
+			t.Error(err)
+		}
+		if !fuzzyEqual(res, d.output) {
+			t.Error("incorrect result of '" + d.input + "', need: " + fmt.Sprintf("%f", d.output) + ", but get: " + fmt.Sprintf("%f", res))
+		}
+	}
+
+	// the variable x of expression isn't visible in the body
+	exp, _ := p.Parse("f(x, 1)")
+	if vars := GetVarList(exp); len(vars) != 1 || vars[0] != "x" {
+		t.Error("incorrect list of variables: " + fmt.Sprint(vars))
+	}
+
+	errorsData := []string{
+		"h(x) = x + z",
+		"h(x, x) = x",
+		"h(1) = 1",
+		"sqrt(x) = x",
+		"if(a, b, c) = a + b + c",
+		"f(1, 2, 3)",
+		"one(1)",
+		"h(x) = x; h(1",
+	}
+	for _, input := range errorsData {
+		if _, err := p.Parse(input); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+	if _, ok := p.Definitions["h"]; ok {
+		t.Error("definition of failed parsing is registered")
+	}
+	if _, ok := p.Operators[0]["h"]; ok {
+		t.Error("function of failed parsing is registered")
+	}
+	if _, ok := p.Operators[0]["if"]; ok {
+		t.Error("special form is redefined")
+	}
+}
+
+func TestRecursion(t *testing.T) {
+	p := NewParser()
+	p.MaxCallDepth = 10
+	p.Parse("loop(x) = loop(x + 1)\nloop(0)")
+	if _, err := p.Evaluate(map[string]float64{}); err == nil {
+		t.Error("recursion depth limit is not checked")
+	}
+	// the depth counter is restored after the error
+	p.MaxCallDepth = 1
+	p.Parse("loop(0)")
+	if _, err := p.Evaluate(map[string]float64{}); err == nil {
+		t.Error("recursion depth limit is not checked")
+	}
+	if p.Definitions["loop"].String() != "loop(x) = loop(x + 1)" {
+		t.Error("incorrect definition = " + p.Definitions["loop"].String())
+	}
+}
+
+func TestExportFunctions(t *testing.T) {
+	p := NewParser()
+	p.Parse("sq(x) = x * x; hyp(a, b) = sqrt(sq(a) + sq(b)); hyp(3, 4)")
+	text := p.ExportFunctions()
+	if text != "hyp(a, b) = sqrt(sq(a) + sq(b))\nsq(x) = x * x\n" {
+		t.Error("incorrect export = " + text)
+	}
+
+	p2 := NewParser()
+	if err := p2.ImportFunctions(text); err != nil {
+		t.Error(err)
+	}
+	p2.Parse("hyp(6, 8)")
+	res, err := p2.Evaluate(map[string]float64{})
+	if err != nil {
+		t.Error(err)
+	}
+	if !fuzzyEqual(res, 10) {
+		t.Error("incorrect result = " + fmt.Sprintf("%f", res))
+	}
+
+	// defined functions participate in the other evaluation modes
+	p2.Parse("sq(x) + 1")
+	_, grad, err := p2.EvaluateGradient(map[string]float64{"x": 3}, "x")
+	if err != nil {
+		t.Error(err)
+	}
+	if !fuzzyEqual(grad["x"], 6) {
+		t.Error("incorrect derivative = " + fmt.Sprintf("%f", grad["x"]))
+	}
+
+	p3 := NewParser()
+	for _, text := range []string{"f(x) = x; 1 + 2", "f(x) = y", "f(x) = x g(x) = x"} {
+		if err := p3.ImportFunctions(text); err == nil {
+			t.Error("incorrect error handling of '" + text + "'")
+		}
+		if len(p3.Definitions) != 0 {
+			t.Error("definitions of failed import are registered")
+		}
+	}
+}
+
+func TestParseLet(t *testing.T) {
+	type TestVars map[string]float64
+	type TestData struct {
+		input  string
+		vars   TestVars
+		output float64
+		used   []string
+	}
+
+	data := []TestData{
+		{"let m = price - cost in m * qty / (m + fee)", TestVars{"price": 10, "cost": 6, "qty": 3, "fee": 2}, 2,
+			[]string{"cost", "fee", "price", "qty"}},
+		{"let a = 2, b = a * 3 in a + b", TestVars{}, 8, []string{}},
+		{"2 * let x = 3 in x + 1", TestVars{}, 8, []string{}},
+		{"let x = x + 1 in x * 2", TestVars{"x": 4}, 10, []string{"x"}},
+		{"(let x = 3 in x) + x", TestVars{"x": 4}, 7, []string{"x"}},
+		{"f(v) = let s = v * v in s + s\nf(3)", TestVars{}, 18, []string{}},
+		{"let index = 1 in index + inside", TestVars{"inside": 2}, 3, []string{"inside"}},
+		{"in - out", TestVars{"in": 5, "out": 2}, 3, []string{"in", "out"}},
+		{"let a = 2 in a * in", TestVars{"in": 3}, 6, []string{"in"}},
+	}
+
+	p := NewParser()
+	for _, d := range data {
+		exp, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		res, err := p.Evaluate(d.vars)
+		if err != nil {
+			t.Error(err)
+		}
+		if !fuzzyEqual(res, d.output) {
+			t.Error("incorrect result of '" + d.input + "', need: " + fmt.Sprintf("%f", d.output) + ", but get: " + fmt.Sprintf("%f", res))
+		}
+		if vars := GetVarList(exp); fmt.Sprint(vars) != fmt.Sprint(d.used) {
+			t.Error("incorrect list of variables of '" + d.input + "': " + fmt.Sprint(vars))
+		}
+	}
+
+	for _, input := range []string{"let x = 1", "let = 1 in 2", "let 1 = 2 in 3", "let x 1 in x", "let x = in in x", "let in = 1 in 2"} {
+		if _, err := p.Parse(input); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+}
+
+func TestConditionals(t *testing.T) {
+	type TestVars map[string]float64
+	type TestData struct {
+		input  string
+		vars   TestVars
+		output float64
+	}
+
+	data := []TestData{
+		{"if(x > 0, sqrt(x), 0)", TestVars{"x": -4}, 0},
+		{"if(x > 0, sqrt(x), 0)", TestVars{"x": 4}, 2},
+		{"iferror(1 / x, -1)", TestVars{"x": 0}, -1},
+		{"piecewise(x < 0, -1, x == 0, 0, 1)", TestVars{"x": 0}, 0},
+		{"case(x < 0, -1, x == 0, 0, 1)", TestVars{"x": 3}, 1},
+		{"coalesce(1 / x, y)", TestVars{"x": 0, "y": 5}, 5},
+		{"1 + 2 < 4", TestVars{}, 1},
+		{"fact(n) = if(n <= 1, 1, n * fact(n - 1))\nfact(5)", TestVars{}, 120},
+	}
+
+	p := NewParser()
+	for _, d := range data {
+		if _, err := p.Parse(d.input); err != nil {
+			t.Error(err)
+			continue
+		}
+		res, err := p.Evaluate(d.vars)
+		if err != nil {
+			t.Error(err)
+		}
+		if !fuzzyEqual(res, d.output) {
+			t.Error("incorrect result of '" + d.input + "', need: " + fmt.Sprintf("%f", d.output) + ", but get: " + fmt.Sprintf("%f", res))
+		}
+	}
+
+	for _, input := range []string{"if(1, 2)", "piecewise(0, 1)"} {
+		if _, err := p.Parse(input); err != nil {
+			continue
+		}
+		if _, err := p.Evaluate(TestVars{}); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+
+	p.AddSpecialForm(func(args ...funcs.Thunk) (float64, error) {
+		for _, arg := range args {
+			val, err := arg()
+			if err != nil || val == 0 {
+				return 0, err
+			}
+		}
+		return 1, nil
+	}, "and")
+	if _, err := p.Parse("and(x != 0, 1 / x > 1)"); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := p.Evaluate(TestVars{"x": 0}); err != nil || res != 0 {
+		t.Error("incorrect result of user's special form")
+	}
+}
+
+func TestConditionalsIn(t *testing.T) {
+	p := NewParser()
+	if _, err := p.Parse("if(x > 0, x * x, 0)"); err != nil {
+		t.Fatal(err)
+	}
+
+	val, grad, err := p.EvaluateGradient(map[string]float64{"x": 3}, "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fuzzyEqual(val, 9) || !fuzzyEqual(grad["x"], 6) {
+		t.Error("incorrect gradient of conditional: " + fmt.Sprint(val, grad))
+	}
+
+	res, err := p.EvaluateInterval(map[string]interval.Interval{"x": {Lo: 1, Hi: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Lo > 1 || res.Hi < 4 || res.Contains(0) {
+		t.Error("incorrect interval of known condition: " + res.String())
+	}
+
+	res, err = p.EvaluateInterval(map[string]interval.Interval{"x": {Lo: -1, Hi: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Contains(0) || !res.Contains(4) {
+		t.Error("incorrect interval of unknown condition: " + res.String())
+	}
+
+	// the conditions call the defined functions
+	p.Parse("sq(x) = x * x")
+	p.Parse("if(x > 0, sq(x), 0)")
+	if res, err := p.EvaluateInterval(map[string]interval.Interval{"x": {Lo: 1, Hi: 2}}); err != nil || res.Contains(0) {
+		t.Error("incorrect interval of condition with definition: " + fmt.Sprint(res, err))
+	}
+
+	// the user's and the replaced special forms aren't evaluated by the domains
+	p.AddSpecialForm(func(args ...funcs.Thunk) (float64, error) { return args[0]() }, "first")
+	p.Parse("first(x, 1)")
+	if _, err := p.EvaluateInterval(map[string]interval.Interval{"x": {Lo: 1, Hi: 2}}); err == nil ||
+		err.Error() != "special form 'first' is not supported in domain" {
+		t.Error("incorrect error handling of user's special form: " + fmt.Sprint(err))
+	}
+	p.AddSpecialForm(func(args ...funcs.Thunk) (float64, error) { return args[2]() }, "if")
+	p.Parse("if(x > 0, x, 0)")
+	if _, _, err := p.EvaluateGradient(map[string]float64{"x": 3}, "x"); err == nil {
+		t.Error("replaced special form is evaluated by the domain")
+	}
+}
+
+func TestImplicitMultiplication(t *testing.T) {
+	type TestVars map[string]float64
+	type TestData struct {
+		input  string
+		output string
+		res    float64
+	}
+	vars := TestVars{"x": 2, "y": 3, "a": 5, "b": 1}
+
+	data := []TestData{
+		{"2x + 3(y - 1)", "( + ( * 2 x ) ( * 3 ( - y 1 ) ) )", 10},
+		{"(a+b)(a-b)", "( * ( + a b ) ( - a b ) )", 24},
+		{"2x^2", "( * 2 ( ^ x 2 ) )", 8},
+		{"x^2y", "( * ( ^ x 2 ) y )", 12},
+		{"1/2x", "( * ( / 1 2 ) x )", 1},
+		{"2sqrt(4)", "( * 2 ( sqrt ( 4 ) ) )", 4},
+		{"x(y + 1)", "( * x ( + y 1 ) )", 8},
+		{"-2x", "( - ( * 2 x ) )", -4},
+		{"1.5e2x", "( * 1.5e2 x )", 300},
+		{"2 x y", "( * ( * 2 x ) y )", 12},
+		{"let k = 2 in 3k", "( let k 2 ( * 3 k ) )", 6},
+	}
+
+	p := NewParser()
+	p.ImplicitMultiplication = true
+	for _, d := range data {
+		exp, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if exp.String() != d.output {
+			t.Error("incorrect parsing of '" + d.input + "': " + exp.String() + ", need: " + d.output)
+		}
+		res, err := p.Evaluate(vars)
+		if err != nil {
+			t.Error(err)
+		}
+		if !fuzzyEqual(res, d.res) {
+			t.Error("incorrect result of '" + d.input + "': " + fmt.Sprintf("%f", res))
+		}
+	}
+
+	if _, err := p.Parse("sqrt 4"); err == nil {
+		t.Error("incorrect error handling of function without parenthesis")
+	}
+
+	// disabled by default
+	p = NewParser()
+	if exp, err := p.Parse("2x"); err != nil || exp.String() != "2x" {
+		t.Error("implicit multiplication must be disabled by default")
+	}
+	if _, err := p.Parse("3(y - 1)"); err == nil {
+		t.Error("implicit multiplication must be disabled by default")
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+	p := NewParser()
+	p.Identifiers = &IdentifierGrammar{Reserved: []string{"pi"}}
+
+	for _, input := range []string{"x_1 + _y * 2", "a = 2; a + b", "let v = 1 in v + w", "f(p, q) = p * q; f(2, 3)", "1.5e3 + x"} {
+		if _, err := p.Parse(input); err != nil {
+			t.Error(err)
+		}
+	}
+
+	type TestData struct {
+		input string
+		err   string
+	}
+	data := []TestData{
+		{"1 + 2x", "incorrect identifier '2x' at 4 position"},
+		{"a.b@c", "incorrect identifier 'a.b@c' at 0 position"},
+		{"y + x$", "incorrect identifier 'x$' at 4 position"},
+		{"2 * pi", "reserved name 'pi' can't be used as variable at 4 position"},
+		{"sqrt + 1", "reserved name 'sqrt' can't be used as variable at 0 position"},
+		{"abs = 3", "reserved name 'abs' can't be used as variable at 0 position"},
+		{"let if = 1 in 2", "reserved name 'if' can't be used as variable at 4 position"},
+		{"g(sqrt) = 1", "reserved name 'sqrt' can't be used as variable at 2 position"},
+		{"pi(x) = x", "reserved name 'pi' can't be used as function at 0 position"},
+		{"x€ = 1", "incorrect identifier 'x€' at 0 position"},
+		{"a b", "unexpected 'b' at 2 position"},
+	}
+	for _, d := range data {
+		_, err := p.Parse(d.input)
+		if err == nil || err.Error() != d.err {
+			t.Error("incorrect error handling of '" + d.input + "': " + fmt.Sprint(err) + ", need: " + d.err)
+		}
+	}
+
+	p.Identifiers.Unicode = true
+	p.Identifiers.Dots = true
+	for _, input := range []string{"длина * 2", "point.x + point.y", "α1 + β"} {
+		if _, err := p.Parse(input); err != nil {
+			t.Error(err)
+		}
+	}
+	for _, input := range []string{"point. + 1", ".x + 1", "a..b", "a.1"} {
+		if _, err := p.Parse(input); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+}
+
+func TestNumericLiterals(t *testing.T) {
+	type TestData struct {
+		input  string
+		output float64
+	}
+	data := []TestData{
+		{"0xFF + 0b11 + 0o10", 266},
+		{"1_000_000 / 1_000", 1000},
+		{"price * 15%", 30},
+		{"price % 7", 4},
+		{"2k + 3M", 3002000},
+		{"-0x10", -16},
+	}
+
+	p := NewParser()
+	p.PercentLiterals = true
+	p.MagnitudeSuffixes = true
+	for _, d := range data {
+		if _, err := p.Parse(d.input); err != nil {
+			t.Error(err)
+			continue
+		}
+		res, err := p.Evaluate(map[string]float64{"price": 200})
+		if err != nil {
+			t.Error(err)
+		}
+		if !fuzzyEqual(res, d.output) {
+			t.Error("incorrect result of '" + d.input + "': " + fmt.Sprintf("%f", res))
+		}
+	}
+
+	p = NewParser()
+	p.ImplicitMultiplication = true
+	if exp, err := p.Parse("0xFF x"); err != nil || exp.String() != "( * 255 x )" {
+		t.Error("incorrect parsing of hex literal with implicit multiplication: " + fmt.Sprint(exp, err))
+	}
+}
+
+func TestUnicodeSymbols(t *testing.T) {
+	type TestData struct {
+		input  string
+		output string
+		res    float64
+	}
+	data := []TestData{
+		{"a × b ÷ 2 − 1", "( - ( / ( * a b ) 2 ) 1 )", 5},
+		{"√a + 1", "( + ( sqrt ( a ) ) 1 )", 3},
+		{"√(a + 5)", "( sqrt ( ( + a 5 ) ) )", 3},
+		{"2 × b²", "( * 2 ( ^ b 2 ) )", 18},
+		{"-b²", "( - ( ^ b 2 ) )", -9},
+		{"a⁻¹", "( ^ a ( - 1 ) )", 0.25},
+		{"b¹⁰ ÷ b⁹", "( / ( ^ b 10 ) ( ^ b 9 ) )", 3},
+		{"a ≤ b", "( <= a b )", 0},
+		{"a ≠ 4", "( != a 4 )", 0},
+		{"2 · π", "( * 2 3.141592653589793 )", 2 * math.Pi},
+	}
+
+	p := NewParser()
+	for _, d := range data {
+		exp, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if exp.String() != d.output {
+			t.Error("incorrect parsing of '" + d.input + "': " + exp.String() + ", need: " + d.output)
+		}
+		res, err := p.Evaluate(map[string]float64{"a": 4, "b": 3})
+		if err != nil {
+			t.Error(err)
+		}
+		if !fuzzyEqual(res, d.res) {
+			t.Error("incorrect result of '" + d.input + "': " + fmt.Sprintf("%f", res))
+		}
+	}
+
+	p.ImplicitMultiplication = true
+	if exp, err := p.Parse("2πr²"); err != nil || exp.String() != "( * ( * 2 3.141592653589793 ) ( ^ r 2 ) )" {
+		t.Error("incorrect parsing with implicit multiplication: " + fmt.Sprint(exp, err))
+	}
+	if exp, err := p.Parse("3√x"); err != nil || exp.String() != "( * 3 ( sqrt ( x ) ) )" {
+		t.Error("incorrect parsing with implicit multiplication: " + fmt.Sprint(exp, err))
+	}
+
+	// symbols are configurable
+	p = NewParser()
+	delete(p.Symbols, "×")
+	p.Symbols["∙"] = "*"
+	p.Superscripts = false
+	if exp, err := p.Parse("a∙b + c×d"); err != nil || exp.String() != "( + ( * a b ) c×d )" {
+		t.Error("incorrect parsing with custom symbols: " + fmt.Sprint(exp, err))
+	}
+	if exp, err := p.Parse("x²"); err != nil || exp.String() != "x²" {
+		t.Error("superscripts must be disabled: " + fmt.Sprint(exp, err))
+	}
+}
+
+func TestLocale(t *testing.T) {
+	type TestData struct {
+		input  string
+		output string
+		res    float64
+	}
+	data := []TestData{
+		{"3,5 * x", "( * 3,5 x )", 7},
+		{"foo(1,5; x)", "( foo ( 1,5;x ) )", 3.5},
+		{"1.234.567,5 - 1.000.000", "( - 1234567,5 1000000 )", 234567.5},
+		{"x * 0,5e2", "( * x 0,5e2 )", 100},
+		{"a = 1,5\na * x", "( = a 1,5 ); ( * a x )", 3},
+	}
+
+	p := NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) { return args[0] + args[1], nil }, "foo")
+	p.Locale = EuropeanLocale
+	for _, d := range data {
+		if _, err := p.Parse(d.input); err != nil {
+			t.Error(err)
+			continue
+		}
+		if p.String() != d.output {
+			t.Error("incorrect string of '" + d.input + "': " + p.String() + ", need: " + d.output)
+		}
+		res, err := p.Evaluate(map[string]float64{"x": 2})
+		if err != nil {
+			t.Error(err)
+		}
+		if !fuzzyEqual(res, d.res) {
+			t.Error("incorrect result of '" + d.input + "': " + fmt.Sprintf("%f", res))
+		}
+	}
+
+	for _, input := range []string{"foo(1, 2)", "1.23 + 1", "1,5,5"} {
+		if _, err := p.Parse(input); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+	if _, err := p.Parse("a = 3,5; a * 2"); err == nil || err.Error() != "unexpected ';' at 7 position" {
+		t.Error("incorrect error of the separator: " + fmt.Sprint(err))
+	}
+
+	type FormatData struct {
+		val    float64
+		output string
+	}
+	for _, d := range []FormatData{{1234567.25, "1.234.567,25"}, {-1234, "-1.234"}, {0.5, "0,5"}, {12, "12"}, {1e22, "1e+22"}} {
+		if res := p.FormatNumber(d.val); res != d.output {
+			t.Error("incorrect format of number: " + res + ", need: " + d.output)
+		}
+	}
+
+	p.Locale = Locale{Decimal: ',', Separator: ','}
+	if _, err := p.Parse("1"); err == nil {
+		t.Error("incorrect error handling of locale with the same separators")
+	}
+
+	p = NewParser()
+	if res := p.FormatNumber(1234.5); res != "1234.5" {
+		t.Error("incorrect format of number in default locale: " + res)
+	}
+}
+
+// formatCorpus - expressions of the tests above, the infix format of them must be parsed to the same tree
+var formatCorpus = []string{
+	"", "x", "x*(sqrt(y)+1)", "(доход-расход)*налог", "f1(1)", "f1()", "15+20", "2^3-10", "sqrt(14+(4^(0.5)))",
+	"10+50+5", "2*2+2", "2*(2+2)", "100+sqrt(3^2+(2*2+3))", "1 + a", "2^(sqrt(14+2))", "abs(- 2)", "- 4",
+	"tax = price * 0.2; total = price + tax; total * qty", "a = 1; a = a + 1; a * 2",
+	"let m = price - cost in m * qty / (m + fee)", "let a = 2, b = a * 3 in a + b", "2 * let x = 3 in x + 1",
+	"(let x = 3 in x) + x", "if(x > 0, sqrt(x), 0)", "piecewise(x < 0, -1, x == 0, 0, 1)", "1 + 2 < 4",
+	"coalesce(1 / x, y)", "a - (b - c)", "a - b - c", "a / (b * c)", "a ^ (b ^ c)", "-a * b", "(-a) * b",
+	"2 * -a * b", "2 * -(a * b)", "-(a + b)", "- -a", "a + -b * c", "(a < b) < c", "a < (b < c)", "x^-1",
+	"abs(-a) * -b ^ 2", "-(-a * b)", "((a))", "2 * (let x = 1 in x) + 1", "to(d, \"km / h\")",
+	"1.5e-3 * 2E+2", "a = let x = 1 in x", "-sqrt(a) ^ 2",
+}
+
+func TestFormat(t *testing.T) {
+	p := NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) { return 1, nil }, "f1")
+	for _, opts := range []FormatOptions{DefaultFormat, {}, {Spaces: true, TightLevel: 1}} {
+		for _, input := range formatCorpus {
+			exp, err := p.Parse(input)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			str := p.Format(exp, opts)
+			formatted, err := p.Parse(str)
+			if err != nil {
+				t.Error("can't parse format of '" + input + "': '" + str + "': " + err.Error())
+				continue
+			}
+			if formatted.String() != exp.String() {
+				t.Error("incorrect format of '" + input + "': '" + str + "' is parsed to " + formatted.String() +
+					", need: " + exp.String())
+			}
+		}
+	}
+
+	type TestData struct {
+		input  string
+		output string
+	}
+	data := []TestData{
+		{"(price - purchasePrice) * numOfGoods", "(price - purchasePrice) * numOfGoods"},
+		{"((a + b)) + (c * d)", "a + b + c * d"},
+		{"a - (b + c)", "a - (b + c)"},
+		{"2 * (-a)", "2 * -a"},
+		{"(-a) * b", "(-a) * b"},
+		{"-(a*b)", "-a * b"},
+		{"foo(a,b)", "foo(a, b)"},
+		{"x²", "x ^ 2"},
+	}
+	p.AddFunction(func(args ...float64) (float64, error) { return 1, nil }, "foo")
+	for _, d := range data {
+		exp, err := p.Parse(d.input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if str := p.Format(exp, DefaultFormat); str != d.output {
+			t.Error("incorrect format of '" + d.input + "': '" + str + "', need: '" + d.output + "'")
+		}
+	}
+
+	exp, _ := p.Parse("a*b + c^2 - foo(1.5, d)")
+	if str := p.Format(exp, FormatOptions{Spaces: true, TightLevel: 1}); str != "a*b + c^2 - foo(1.5, d)" {
+		t.Error("incorrect format with tight level: " + str)
+	}
+	if str := p.Format(exp, FormatOptions{}); str != "a*b+c^2-foo(1.5,d)" {
+		t.Error("incorrect compact format: " + str)
+	}
+
+	p.Locale = EuropeanLocale
+	exp, _ = p.Parse("a = foo(1,5; d)\na * 2,5")
+	if str := p.Format(exp, DefaultFormat); str != "a = foo(1,5; d)\na * 2,5" {
+		t.Error("incorrect format in locale: " + str)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	p := NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) { return 1, nil }, "f1")
+	for _, input := range formatCorpus {
+		exp, err := p.Parse(input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		data, err := p.MarshalExpression(exp)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		loaded, err := p.UnmarshalExpression(data)
+		if err != nil {
+			t.Error("can't load JSON of '" + input + "': " + string(data) + ": " + err.Error())
+			continue
+		}
+		if loaded.String() != exp.String() {
+			t.Error("incorrect JSON of '" + input + "': " + string(data) + " is loaded to " + loaded.String())
+		}
+	}
+
+	exp, _ := p.Parse("-x + f1(2.50, \"km\")")
+	data, err := p.MarshalExpression(exp)
+	need := `{"version":1,"root":{"kind":"binary","op":"+","left":{"kind":"unary","op":"-","operand":` +
+		`{"kind":"variable","name":"x"}},"right":{"kind":"call","name":"f1","args":[{"kind":"number","value":"2.50"},` +
+		`{"kind":"string","value":"km"}]}}}`
+	if err != nil || string(data) != need {
+		t.Error("incorrect JSON: " + string(data))
+	}
+
+	// without the identifier grammar the names are the terms of the lexer
+	for _, name := range []string{"a b", "a+b", "f(x)", "let", "\"s\"", ""} {
+		input := `{"version":1,"root":{"kind":"let","name":` + strconv.Quote(name) +
+			`,"value":{"kind":"number","value":"1"},"body":{"kind":"variable","name":` + strconv.Quote(name) + `}}}`
+		if _, err := p.UnmarshalExpression([]byte(input)); err == nil {
+			t.Error("incorrect error handling of name '" + name + "'")
+		}
+	}
+
+	p.Parse("sq(x) = x * x")
+	p.Identifiers = &IdentifierGrammar{Reserved: []string{"tmp"}}
+	errorsData := []string{
+		``,
+		`{"root":{"kind":"variable","name":"x"}}`,
+		`{"version":2,"root":{"kind":"variable","name":"x"}}`,
+		`{"version":1}`,
+		`{"version":1,"root":{"kind":"matrix"}}`,
+		`{"version":1,"root":{"kind":"number","value":"abc"}}`,
+		`{"version":1,"root":{"kind":"variable","name":"1.5"}}`,
+		`{"version":1,"root":{"kind":"binary","op":"+","left":{"kind":"variable","name":"x"}}}`,
+		`{"version":1,"root":{"kind":"binary","op":"<>","left":{"kind":"variable","name":"x"},"right":{"kind":"number","value":"1"}}}`,
+		`{"version":1,"root":{"kind":"unary","op":"~","operand":{"kind":"variable","name":"x"}}}`,
+		`{"version":1,"root":{"kind":"call","name":"unknown","args":[]}}`,
+		`{"version":1,"root":{"kind":"call","name":"sq","args":[]}}`,
+		`{"version":1,"root":{"kind":"variable","name":"tmp"}}`,
+		`{"version":1,"root":{"kind":"variable","name":"a.b"}}`,
+		`{"version":1,"root":{"kind":"script","statements":[]}}`,
+	}
+	for _, input := range errorsData {
+		if _, err := p.UnmarshalExpression([]byte(input)); err == nil {
+			t.Error("incorrect error handling of '" + input + "'")
+		}
+	}
+
+	exp, err = p.UnmarshalExpression([]byte(`{"version":1,"root":{"kind":"call","name":"sq","args":[{"kind":"variable","name":"y"}]}}`))
+	if err != nil {
+		t.Error(err)
+	} else if res, err := p.Evaluate(map[string]float64{"y": 3}); err != nil || res != 9 || exp != p.Expression {
+		t.Error("incorrect evaluation of loaded expression: " + strconv.FormatFloat(res, 'g', -1, 64))
+	}
+}
+
+func TestCompile(t *testing.T) {
+	p := NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) { return args[0] * 10, nil }, "f1")
+	p.Parse("sq(x) = x * x")
+	corpus := []string{
+		"", "2.5", "c", "a * (b - 1.5) / c", "-a + +b % 3 ^ 2", "sqrt(a - b) + abs(c)", "f1(a) + sq(b)",
+		"a / (b - b)", "if(a > b, a, b)", "if(a, sqrt(-1), 1) * 2", "piecewise(a < 0, -1, a == 0, 0)",
+		"coalesce(1 / c, b)", "iferror(sqrt(b - a), -1)", "let m = a - b in m * m + (let m = 2 in m)",
+		"tax = a * 0.2; total = a + tax; total * b", "a = a + 1; a * 2", "to(a, \"km\")", "a != b <= c", "a % 0.5 + b % 2.5",
+	}
+	vars := []map[string]float64{
+		{"a": 4, "b": 2, "c": 5},
+		{"a": -1, "b": 0, "c": 0},
+		{"a": 0, "b": -3, "c": 0.5},
+	}
+	for _, input := range corpus {
+		exp, err := p.Parse(input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		f, order, err := p.Compile(exp)
+		if err != nil {
+			t.Error("can't compile '" + input + "': " + err.Error())
+			continue
+		}
+		if fmt.Sprint(order) != fmt.Sprint(GetVarList(exp)) {
+			t.Error("incorrect variables of '" + input + "': " + fmt.Sprint(order))
+		}
+		for _, v := range vars {
+			values := make([]float64, len(order))
+			for i, name := range order {
+				values[i] = v[name]
+			}
+			res, err := f(values)
+			need, needErr := exp.Evaluate(v, p)
+			if fmt.Sprint(err) != fmt.Sprint(needErr) || err == nil && res != need {
+				t.Error("incorrect result of '" + input + "' with " + fmt.Sprint(v) + ": " + fmt.Sprint(res, err) +
+					", need: " + fmt.Sprint(need, needErr))
+			}
+			for i, name := range order {
+				if values[i] != v[name] {
+					t.Error("values are modified by '" + input + "'")
+				}
+			}
+		}
+	}
+
+	exp, _ := p.Parse("x + y")
+	f, _, _ := p.Compile(exp)
+	if _, err := f([]float64{1}); err == nil {
+		t.Error("incorrect error handling of the count of values")
+	}
+	if mod, _ := p.Parse("x % 0.5"); mod == nil {
+		t.Error("can't parse the fractional divisor")
+	} else if _, err := mod.Evaluate(map[string]float64{"x": 3}, p); err == nil {
+		t.Error("incorrect error handling of the fractional divisor")
+	}
+	p.Operators[2]["+"] = func(args ...float64) (float64, error) { return args[0] - args[1], nil }
+	if f, _, err := p.Compile(exp); err != nil {
+		t.Error(err)
+	} else if res, err := f([]float64{3, 1}); err != nil || res != 2 {
+		t.Error("incorrect result of replaced operator: " + fmt.Sprint(res, err))
+	}
+	exp, _ = p.Parse("f1(x)")
+	delete(p.Operators[0], "f1")
+	if _, _, err := p.Compile(exp); err == nil {
+		t.Error("incorrect error handling of unknown function")
+	}
+
+	// each call of the definition counts its own depth of recursion
+	p.MaxCallDepth = 10
+	p.Parse("fact(n) = if(n <= 1, 1, n * fact(n - 1))")
+	exp, _ = p.Parse("fact(x)")
+	f, _, _ = p.Compile(exp)
+	var wg sync.WaitGroup
+	results := make([]error, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100 && results[i] == nil; j++ {
+				if res, err := f([]float64{10}); err != nil || res != 3628800 {
+					results[i] = errors.New("incorrect concurrent result: " + fmt.Sprint(res, err))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range results {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := f([]float64{11}); err == nil {
+		t.Error("incorrect error handling of the recursion depth")
+	}
+}
+
+func TestEvaluateColumns(t *testing.T) {
+	p := NewParser()
+	p.AddFunction(func(args ...float64) (float64, error) { return args[0] * 10, nil }, "f1")
+	p.AddSpecialForm(func(args ...funcs.Thunk) (float64, error) { return args[len(args)-1]() }, "last")
+	p.Parse("sq(x) = x * x")
+	corpus := []string{
+		"", "2.5", "c", "a * (b - 1.5) / c", "-a + +b % 3 ^ 2", "sqrt(a - b) + abs(c)", "f1(a) + sq(b)",
+		"a / (b - b)", "if(a > b, a, b)", "if(a, sqrt(-1), 1) * 2", "piecewise(a < 0, -1, a == 0, 0)",
+		"coalesce(1 / c, b)", "iferror(sqrt(b - a), -1)", "let m = a - b in m * m + (let m = 2 in m)",
+		"let m = sqrt(a) in 1 / c", "tax = a * 0.2; total = a + tax; total * b", "a = a + 1; a * 2", "a % 0.5 + b % 2.5",
+		"x = 1 / c; y = sqrt(a); x + y", "to(a, \"km\")", "a != b <= c", "last(1 / b, c)", "unknown + a",
+		"let m = a - b in if(m > 0, if(c, m / c, sqrt(m)), iferror(f1(m) / b, m))", "coalesce(sq(c) / b, if(a, a, 1 / c))",
+	}
+	columns := map[string][]float64{
+		"a": {4, -1, 0, 9, 2},
+		"b": {2, 0, -3, 9, 1},
+		"c": {5, 0, 0.5, -2, 0},
+	}
+	for _, input := range corpus {
+		exp, err := p.Parse(input)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		for _, opts := range []ColumnOptions{{}, {ChunkSize: 2, Workers: 2}} {
+			p.Expression = exp
+			res, errs := p.EvaluateColumnsWith(columns, opts)
+			if len(res) != 5 {
+				t.Fatal("incorrect count of results: " + strconv.Itoa(len(res)))
+			}
+			for i := range res {
+				vars := map[string]float64{"a": columns["a"][i], "b": columns["b"][i], "c": columns["c"][i]}
+				need, needErr := exp.Evaluate(vars, p)
+				var err error
+				if errs != nil {
+					err = errs[i]
+				}
+				if fmt.Sprint(err) != fmt.Sprint(needErr) || err == nil && !(res[i] == need || math.IsNaN(need)) {
+					t.Error("incorrect result of '" + input + "' in row " + strconv.Itoa(i) + ": " +
+						fmt.Sprint(res[i], err) + ", need: " + fmt.Sprint(need, needErr))
+				}
+			}
+		}
+	}
+
+	p.Parse("coalesce(a, b) + iferror(b, 0)")
+	nulls := map[string][]bool{"a": {true, false, true, false, false}, "b": {false, false, true, false, true}}
+	res, errs := p.EvaluateColumnsWith(columns, ColumnOptions{Nulls: nulls})
+	need := []float64{4, -1, 0, 18, 2}
+	for i := range res {
+		if i == 2 && errs[i] != ErrNull || i != 2 && (errs[i] != nil || res[i] != need[i]) {
+			t.Error("incorrect result of row " + strconv.Itoa(i) + " with nulls: " + fmt.Sprint(res[i], errs[i]))
+		}
+	}
+	if res, errs := p.EvaluateColumns(columns); errs != nil || res[3] != 18 {
+		t.Error("incorrect result without errors: " + fmt.Sprint(res, errs))
+	}
+
+	// the arguments of special forms are evaluated only for the rows, which need them
+	calls := 0
+	p.AddFunction(func(args ...float64) (float64, error) {
+		calls++
+		return args[0], nil
+	}, "count")
+	p.Parse("if(a > 0, count(b), -count(c))")
+	res, errs = p.EvaluateColumnsWith(columns, ColumnOptions{Nulls: map[string][]bool{"b": {false, true, false, false, false}}})
+	if errs != nil || fmt.Sprint(res) != "[2 -0 -0.5 9 1]" || calls != 5 {
+		t.Error("incorrect result of the special form: " + fmt.Sprint(res, errs, calls))
+	}
+
+	badData := []struct {
+		columns map[string][]float64
+		nulls   map[string][]bool
+	}{
+		{map[string][]float64{"a": {1, 2}, "b": {1}}, nil},
+		{columns, map[string][]bool{"a": {true}}},
+		{columns, map[string][]bool{"x": {true, true, true, true, true}}},
+	}
+	for _, d := range badData {
+		res, errs := p.EvaluateColumnsWith(d.columns, ColumnOptions{Nulls: d.nulls})
+		if len(errs) != len(res) || len(errs) == 0 || errs[0] == nil {
+			t.Error("incorrect error handling of the columns: " + fmt.Sprint(d.columns, d.nulls))
+		}
+	}
+}
+
+const benchmarkFormula = "price * qty * (1 - discount / 100) + sqrt(fee) - if(qty > 10, 5, 0)"
+
+func benchmarkColumns(rows int) map[string][]float64 {
+	columns := map[string][]float64{}
+	for _, name := range []string{"price", "qty", "discount", "fee"} {
+		col := make([]float64, rows)
+		for i := range col {
+			col[i] = float64(i%97 + len(name))
+		}
+		columns[name] = col
+	}
+	return columns
+}
+
+func BenchmarkEvaluateRows(b *testing.B) {
+	p := NewParser()
+	p.Parse(benchmarkFormula)
+	columns := benchmarkColumns(100000)
+	vars := make(map[string]float64, len(columns))
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := 0; i < 100000; i++ {
+			for name, col := range columns {
+				vars[name] = col[i]
+			}
+			p.Evaluate(vars)
+		}
+	}
+}
+
+func BenchmarkEvaluateColumns(b *testing.B) {
+	p := NewParser()
+	p.Parse(benchmarkFormula)
+	columns := benchmarkColumns(100000)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		p.EvaluateColumns(columns)
+	}
+}
+
+func BenchmarkEvaluateColumnsParallel(b *testing.B) {
+	p := NewParser()
+	p.Parse(benchmarkFormula)
+	columns := benchmarkColumns(100000)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		p.EvaluateColumnsWith(columns, ColumnOptions{Workers: 4})
+	}
+}